@@ -1,39 +1,201 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 type Callee interface {
-	arity() int
+	// Minimum and maximum number of arguments, the maximum being
+	// VARIADIC when there is no upper bound
+	arity() (int, int)
 	call(i *Interpreter, token *Token, args *[]Expression) (any, error)
 	toString() string
 }
 
-func (f *FunctionDeclaration) call(interpreter *Interpreter, token *Token, args *[]Expression) (any, error) {
+const VARIADIC = -1
+
+// Applies to params and to calls once spread arguments are expanded
+const MAX_ARGS = 255
+
+// "2", "1 to 2" or "at least 1"
+func arityRange(callee Callee) string {
+	min, max := callee.arity()
+	if max == VARIADIC {
+		return fmt.Sprintf("at least %d", min)
+	}
+	if min == max {
+		return fmt.Sprint(min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
+
+// Evaluates the positional arguments of a call, expanding `...list`.
+// Named arguments are returned apart without being evaluated
+func (i *Interpreter) evaluatePositional(token *Token, args *[]Expression) ([]any, []*NamedArgument, error) {
 	argsVal := make([]any, 0)
+	named := []*NamedArgument{}
 	for _, arg := range *args {
-		val, err := arg.accept(interpreter)
+		if n, ok := arg.(*NamedArgument); ok {
+			named = append(named, n)
+			continue
+		}
+		if spread, ok := arg.(*Spread); ok {
+			elements, err := i.spread(spread)
+			if err != nil {
+				return nil, nil, err
+			}
+			argsVal = append(argsVal, elements...)
+			continue
+		}
+		val, err := arg.accept(i)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		argsVal = append(argsVal, val)
 	}
 
+	if len(argsVal) > MAX_ARGS {
+		return nil, nil, CreateRuntimeError(token, fmt.Sprintf("Can't have more than %d args", MAX_ARGS))
+	}
+	return argsVal, named, nil
+}
+
+// Evaluates the arguments of a call and checks them against the callee arity
+func (i *Interpreter) evaluateArgs(callee Callee, token *Token, args *[]Expression) ([]any, error) {
+	argsVal, named, err := i.evaluatePositional(token, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(named) > 0 {
+		return nil, CreateRuntimeError(named[0].Name, "Unknown parameter "+named[0].Name.Lexeme+" in call to "+callee.toString())
+	}
+
+	min, max := callee.arity()
+	if len(argsVal) < min || (max != VARIADIC && len(argsVal) > max) {
+		return nil, CreateRuntimeError(token, fmt.Sprintf("Expected %s arguments but got %d", arityRange(callee), len(argsVal)))
+	}
+	return argsVal, nil
+}
+
+// Matches positional then named arguments to the params, extra positional
+// arguments going to the rest param. Params left without argument are nil
+// in the result
+func (f *FunctionDeclaration) bindArgs(interpreter *Interpreter, token *Token, args *[]Expression) ([]any, []bool, *List, error) {
+	positional, named, err := interpreter.evaluatePositional(token, args)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	argsVal := make([]any, len(f.Params))
+	given := make([]bool, len(f.Params))
+	for idx := range min(len(positional), len(f.Params)) {
+		argsVal[idx], given[idx] = positional[idx], true
+	}
+	var rest *List = nil
+	if f.Rest != nil {
+		rest = CreateList([]any{})
+		if len(positional) > len(f.Params) {
+			rest.Elements = append(rest.Elements, positional[len(f.Params):]...)
+		}
+	} else if len(positional) > len(f.Params) {
+		return nil, nil, nil, CreateRuntimeError(token, fmt.Sprintf("Expected %s arguments but got %d", arityRange(f), len(positional)))
+	}
+
+	for _, arg := range named {
+		idx := slices.IndexFunc(f.Params, func(param *Token) bool { return param.Lexeme == arg.Name.Lexeme })
+		if idx == -1 {
+			return nil, nil, nil, CreateRuntimeError(arg.Name, "Unknown parameter "+arg.Name.Lexeme+" in call to "+f.toString())
+		}
+		if given[idx] {
+			return nil, nil, nil, CreateRuntimeError(arg.Name, "Got multiple values for parameter "+arg.Name.Lexeme)
+		}
+		val, err := arg.Value.accept(interpreter)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		argsVal[idx], given[idx] = val, true
+	}
+
+	for idx, param := range f.Params {
+		if !given[idx] && f.Defaults[idx] == nil {
+			return nil, nil, nil, CreateRuntimeError(token, "Missing argument for parameter "+param.Lexeme+", expected "+arityRange(f)+" arguments")
+		}
+	}
+	return argsVal, given, rest, nil
+}
+
+// A function value : the declaration and the environment it was declared in
+type Closure struct {
+	Declaration *FunctionDeclaration
+	Env         *Environment
+	// Keeps alive the scopes Env only weakly encloses
+	scopes []*Environment
+}
+
+func (c *Closure) call(interpreter *Interpreter, token *Token, args *[]Expression) (any, error) {
+	return c.Declaration.callIn(interpreter, c.Env, token, args)
+}
+
+func (c *Closure) arity() (int, int) {
+	return c.Declaration.arity()
+}
+
+func (c *Closure) toString() string {
+	return c.Declaration.toString()
+}
+
+func (f *FunctionDeclaration) call(interpreter *Interpreter, token *Token, args *[]Expression) (any, error) {
+	return f.callIn(interpreter, interpreter.Environment, token, args)
+}
+
+// Runs the body in a new scope enclosed by env. Generators only bind their
+// arguments, the body runs when the generator is iterated
+func (f *FunctionDeclaration) callIn(interpreter *Interpreter, env *Environment, token *Token, args *[]Expression) (any, error) {
 	prevEnv := interpreter.Environment
 	defer func() {
 		interpreter.Environment = prevEnv
 	}()
 
-	newEnv := CreateEnvironment(prevEnv, interpreter)
-	interpreter.Environment = newEnv
+	err := f.bindParams(interpreter, env, token, args)
+	if err != nil {
+		return nil, err
+	}
+	if f.Generator {
+		return CreateGenerator(interpreter.fork(interpreter.Environment), f), nil
+	}
+	return f.run(interpreter)
+}
 
-	if f.arity() != len(*args) {
-		return nil, CreateRuntimeError(token, fmt.Sprintf("Expected %d arguments but got %d .", f.arity(), len(*args)))
+// Makes the current environment a new scope enclosed by env and holding the
+// params
+func (f *FunctionDeclaration) bindParams(interpreter *Interpreter, env *Environment, token *Token, args *[]Expression) error {
+	argsVal, given, rest, err := f.bindArgs(interpreter, token, args)
+	if err != nil {
+		return err
 	}
 
+	newEnv := CreateEnvironment(env, interpreter)
+	interpreter.Environment = newEnv
+
 	for i, param := range f.Params {
+		if !given[i] {
+			// Evaluated at each call, after the params before it are bound
+			val, err := f.Defaults[i].accept(interpreter)
+			if err != nil {
+				return err
+			}
+			argsVal[i] = val
+		}
 		interpreter.Environment.Set(param.Lexeme, argsVal[i])
 	}
+	if f.Rest != nil {
+		interpreter.Environment.Set(f.Rest.Lexeme, rest)
+	}
+	return nil
+}
 
+func (f *FunctionDeclaration) run(interpreter *Interpreter) (any, error) {
 	for _, stmt := range f.Stmts {
 		val, err := stmt.accept(interpreter)
 		if err != nil {
@@ -49,8 +211,15 @@ func (f *FunctionDeclaration) call(interpreter *Interpreter, token *Token, args
 	return nil, nil
 }
 
-func (f *FunctionDeclaration) arity() int {
-	return len(f.Params)
+func (f *FunctionDeclaration) arity() (int, int) {
+	required := slices.IndexFunc(f.Defaults, func(d Expression) bool { return d != nil })
+	if required == -1 {
+		required = len(f.Params)
+	}
+	if f.Rest != nil {
+		return required, VARIADIC
+	}
+	return required, len(f.Params)
 }
 
 func (f *FunctionDeclaration) toString() string {
@@ -0,0 +1,984 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// The type checker runs between the resolver and the interpreter when
+// enabled with `--check`. Typing is gradual : literals have their own type,
+// annotated names have the annotated type, and everything else is `any`,
+// which is compatible with every type. Annotations have no effect at
+// runtime.
+//
+//	let x: number = 1;
+//	fun f(a: string, b: number?): bool { ... }
+//
+// The same walk always runs in inference mode, see CreateTypeInference.
+type Type struct {
+	Name     string
+	Nullable bool
+	// Declaration of a function in scope, to check calls against
+	Function *FunctionDeclaration
+	// Declaration of an enum in scope, to check its variants are covered
+	Enum *EnumDeclaration
+}
+
+// `number` accepts every numeric flavour
+var typeNames = []string{
+	"any", "number", "int", "float", "bigint", "decimal", "string", "bool",
+	"nil", "list", "tuple", "range", "map", "function", "generator", "channel", "task",
+}
+
+var numericTypes = []string{"number", "int", "float", "bigint", "decimal"}
+
+var AnyType = &Type{Name: "any"}
+
+func (t *Type) String() string {
+	if t.Nullable {
+		return t.Name + "?"
+	}
+	return t.Name
+}
+
+func (t *Type) isAny() bool {
+	return t.Name == "any"
+}
+
+func (t *Type) isNumeric() bool {
+	return !t.Nullable && slices.Contains(numericTypes, t.Name)
+}
+
+func (t *Type) isString() bool {
+	return !t.Nullable && t.Name == "string"
+}
+
+// Whether a value of type source can be stored where target is expected
+func (target *Type) accepts(source *Type) bool {
+	if target.isAny() || source.isAny() {
+		return true
+	}
+	if source.Name == "nil" {
+		return target.Nullable || target.Name == "nil"
+	}
+	if source.Nullable && !target.Nullable {
+		return false
+	}
+	if target.Name == source.Name {
+		return true
+	}
+	return target.Name == "number" && slices.Contains(numericTypes, source.Name)
+}
+
+// Result of arithmetic on two numeric types, following the promotions of
+// `arithmetic`. Ints overflow into bigints, so their results are only known
+// to be numbers
+func (c *TypeChecker) numericResult(operator *Token, left *Type, right *Type) *Type {
+	if (left.Name == "float" && right.Name == "decimal") || (left.Name == "decimal" && right.Name == "float") {
+		c.report(operator, "Cannot mix decimal and float, convert one of them first")
+		return &Type{Name: "number"}
+	}
+	if left.Name == "int" && right.Name == "int" {
+		return &Type{Name: "number"}
+	}
+	if left.Name == right.Name {
+		return &Type{Name: left.Name}
+	}
+	if left.Name == "int" {
+		return &Type{Name: right.Name}
+	}
+	if right.Name == "int" {
+		return &Type{Name: left.Name}
+	}
+	return &Type{Name: "number"}
+}
+
+// Whether a value of this type may pass `checkExprNumber`
+func (t *Type) mayBeNumeric() bool {
+	return t.isAny() || slices.Contains(numericTypes, t.Name)
+}
+
+func (t *Type) mayBeString() bool {
+	return t.isAny() || t.Name == "string"
+}
+
+// Least specific type of both, `any` when they differ
+func joinTypes(left *Type, right *Type) *Type {
+	if left.Name == "nil" && right.Name != "nil" {
+		return &Type{Name: right.Name, Nullable: true, Function: right.Function, Enum: right.Enum}
+	}
+	if right.Name == "nil" && left.Name != "nil" {
+		return &Type{Name: left.Name, Nullable: true, Function: left.Function, Enum: left.Enum}
+	}
+	if left.Name != right.Name {
+		return AnyType
+	}
+	function, enum := left.Function, left.Enum
+	if left.Function != right.Function {
+		function = nil
+	}
+	if left.Enum != right.Enum {
+		enum = nil
+	}
+	return &Type{Name: left.Name, Nullable: left.Nullable || right.Nullable, Function: function, Enum: enum}
+}
+
+type binding struct {
+	declaration *Token
+	// Type given by the annotation, or by the initializer in inference mode
+	initial *Type
+	// Type at the current point of the flow, in inference mode
+	current *Type
+	// Function declaring the binding, nil at the top level
+	function *FunctionDeclaration
+}
+
+// Type of each binding at some point of the flow
+type flowState map[*binding]*Type
+
+type TypeChecker struct {
+	*Lox
+	// Names declared in each block, globals at the bottom. Names not found
+	// are dynamic
+	scopes []map[string]*binding
+	// Declared return type of the function being checked, nil when dynamic
+	returnType *Type
+	function   *FunctionDeclaration
+
+	// Infer the types of every name and warn only about operations bound to
+	// fail at runtime
+	inference bool
+	// Don't report anything, while collecting assignments or looking for the
+	// types at the start of a loop
+	silent bool
+	// Bindings assigned after their declaration
+	reassigned map[*Token]bool
+	// Bindings assigned from another function than the declaring one,
+	// their type is unknown at any point
+	escaping map[*Token]bool
+}
+
+func CreateTypeChecker(lox *Lox) *TypeChecker {
+	return &TypeChecker{
+		Lox:        lox,
+		scopes:     []map[string]*binding{{}},
+		reassigned: map[*Token]bool{},
+		escaping:   map[*Token]bool{},
+	}
+}
+
+// Infers the types of unannotated code, following them through `let`
+// bindings, assignments, branches and loops, and warns about operations
+// that are guaranteed to fail, such as `"a" - 1` or calling a number.
+//
+// Variables assigned from a function other than the one declaring them
+// could change at any call and are left dynamic. Functions read variables
+// of enclosing functions as dynamic unless they are never reassigned.
+func CreateTypeInference(lox *Lox) *TypeChecker {
+	checker := CreateTypeChecker(lox)
+	checker.inference = true
+	return checker
+}
+
+func (c *TypeChecker) check(statements []Statement) {
+	if c.inference {
+		// A first pass finds the reassigned bindings
+		c.silent = true
+		c.checkStmts(statements)
+		c.scopes = []map[string]*binding{{}}
+		c.silent = false
+	}
+	c.checkStmts(statements)
+}
+
+func (c *TypeChecker) checkStmts(statements []Statement) {
+	for _, stmt := range statements {
+		c.checkStmt(stmt)
+	}
+}
+
+// Errors when checking, warnings when inferring
+func (c *TypeChecker) report(token *Token, msg string) {
+	if c.silent {
+		return
+	}
+	if c.inference {
+		c.Lox.Warn(token, msg)
+	} else {
+		c.Lox.Error(token, msg)
+	}
+}
+
+// Mismatches with annotations are only reported when checking, they don't
+// fail at runtime
+func (c *TypeChecker) reportMismatch(token *Token, msg string) {
+	if !c.inference {
+		c.report(token, msg)
+	}
+}
+
+func (c *TypeChecker) snapshot() flowState {
+	state := flowState{}
+	for _, scope := range c.scopes {
+		for _, b := range scope {
+			state[b] = b.current
+		}
+	}
+	return state
+}
+
+func (c *TypeChecker) restore(state flowState) {
+	for b, t := range state {
+		b.current = t
+	}
+}
+
+// Bindings of both states get the join of their types
+func joinStates(left flowState, right flowState) flowState {
+	state := flowState{}
+	for b, t := range left {
+		if other, found := right[b]; found {
+			state[b] = joinTypes(t, other)
+		}
+	}
+	return state
+}
+
+func sameStates(left flowState, right flowState) bool {
+	for b, t := range left {
+		if other := right[b]; other == nil || other.String() != t.String() || other.Function != t.Function || other.Enum != t.Enum {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks the branches from the same state and joins the resulting states.
+// Without fallback, the state before the branches is joined too
+func (c *TypeChecker) checkBranches(branches []func(), fallback bool) {
+	before := c.snapshot()
+	var after flowState = nil
+	for _, branch := range branches {
+		c.restore(before)
+		branch()
+		if after == nil {
+			after = c.snapshot()
+		} else {
+			after = joinStates(after, c.snapshot())
+		}
+	}
+	if after == nil || !fallback {
+		if after == nil {
+			after = before
+		} else {
+			after = joinStates(after, before)
+		}
+	}
+	c.restore(after)
+}
+
+// Finds the types at the start of the loop before checking its body once
+func (c *TypeChecker) checkLoop(body func()) {
+	if !c.inference {
+		body()
+		return
+	}
+
+	entry := c.snapshot()
+	silent := c.silent
+	c.silent = true
+	for range 8 {
+		c.restore(entry)
+		body()
+		next := joinStates(entry, c.snapshot())
+		if sameStates(entry, next) {
+			break
+		}
+		entry = next
+	}
+	c.silent = silent
+
+	c.restore(entry)
+	body()
+	c.restore(joinStates(entry, c.snapshot()))
+}
+
+func (c *TypeChecker) checkStmt(stmt Statement) {
+	stmt.accept(c)
+}
+
+func (c *TypeChecker) typeOf(expr Expression) *Type {
+	t, _ := expr.accept(c)
+	return t.(*Type)
+}
+
+func (c *TypeChecker) beginScope() {
+	c.scopes = append(c.scopes, map[string]*binding{})
+}
+
+func (c *TypeChecker) endScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *TypeChecker) declare(name *Token, t *Type) {
+	c.scopes[len(c.scopes)-1][name.Lexeme] = &binding{
+		declaration: name,
+		initial:     t,
+		current:     t,
+		function:    c.function,
+	}
+}
+
+func (c *TypeChecker) find(name string) *binding {
+	for idx := len(c.scopes) - 1; idx >= 0; idx -= 1 {
+		if b, found := c.scopes[idx][name]; found {
+			return b
+		}
+	}
+	return nil
+}
+
+func (c *TypeChecker) lookUp(name string) *Type {
+	b := c.find(name)
+	if b == nil {
+		return AnyType
+	}
+	if !c.inference {
+		return b.initial
+	}
+	if b.function != c.function {
+		// Could be read at any time after the enclosing function goes on
+		if c.reassigned[b.declaration] {
+			return AnyType
+		}
+		return b.initial
+	}
+	if c.escaping[b.declaration] {
+		return AnyType
+	}
+	return b.current
+}
+
+// Records the new type of an assigned name, returns the type it must accept
+func (c *TypeChecker) assign(name *Token, value *Type) *Type {
+	b := c.find(name.Lexeme)
+	if b == nil {
+		return AnyType
+	}
+	c.reassigned[b.declaration] = true
+	if b.function != c.function {
+		c.escaping[b.declaration] = true
+	}
+	b.current = value
+	if c.inference {
+		return AnyType
+	}
+	return b.initial
+}
+
+// nil annotations are dynamic, annotations are ignored when inferring
+func (c *TypeChecker) annotated(annotation *TypeAnnotation) *Type {
+	if annotation == nil || c.inference {
+		return AnyType
+	}
+	if !slices.Contains(typeNames, annotation.Name.Lexeme) {
+		c.report(annotation.Name, "Unknown type "+annotation.Name.Lexeme)
+		return AnyType
+	}
+	return &Type{Name: annotation.Name.Lexeme, Nullable: annotation.Nullable}
+}
+
+func (c *TypeChecker) VisitLiteral(l *Literal) any {
+	switch l.Value.(type) {
+	case nil, Nil:
+		return &Type{Name: "nil"}
+	case int64:
+		return &Type{Name: "int"}
+	case float64:
+		return &Type{Name: "float"}
+	case *big.Int:
+		return &Type{Name: "bigint"}
+	case *Decimal:
+		return &Type{Name: "decimal"}
+	case string:
+		return &Type{Name: "string"}
+	case bool:
+		return &Type{Name: "bool"}
+	}
+	return AnyType
+}
+
+func (c *TypeChecker) VisitIdentifier(i *IdentifierExpr) (any, error) {
+	return c.lookUp(i.name.Lexeme), nil
+}
+
+func (c *TypeChecker) expectNumber(operator *Token, t *Type) {
+	if t.isAny() || t.isNumeric() || (c.inference && t.mayBeNumeric()) {
+		return
+	}
+	c.report(operator, "Operand of '"+operator.Lexeme+"' must be a number but got "+t.String())
+}
+
+func (c *TypeChecker) VisitUnary(u *Unary) (any, error) {
+	right := c.typeOf(u.Right)
+	if u.Operand.Type == BANG {
+		return &Type{Name: "bool"}, nil
+	}
+	c.expectNumber(u.Operand, right)
+	if right.isAny() {
+		return &Type{Name: "number"}, nil
+	}
+	return right, nil
+}
+
+func (c *TypeChecker) VisitBinary(b *Binary) (any, error) {
+	left, right := c.typeOf(b.Left), c.typeOf(b.Right)
+
+	switch b.Operator.Type {
+	case MINUS, STAR, SLASH:
+		c.expectNumber(b.Operator, left)
+		c.expectNumber(b.Operator, right)
+		if left.isNumeric() && right.isNumeric() {
+			return c.numericResult(b.Operator, left, right), nil
+		}
+		return &Type{Name: "number"}, nil
+
+	case PLUS:
+		for _, operand := range []*Type{left, right} {
+			valid := operand.isAny() || operand.isNumeric() || operand.isString()
+			if c.inference {
+				valid = operand.mayBeNumeric() || operand.mayBeString()
+			}
+			if !valid {
+				c.report(b.Operator, "Operand of '+' must be a number or a string but got "+operand.String())
+				return AnyType, nil
+			}
+		}
+		if left.isNumeric() && right.isNumeric() {
+			return c.numericResult(b.Operator, left, right), nil
+		}
+		if left.isString() || right.isString() {
+			return &Type{Name: "string"}, nil
+		}
+		return AnyType, nil
+
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL:
+		c.expectNumber(b.Operator, left)
+		c.expectNumber(b.Operator, right)
+
+	case IN:
+		if !right.isAny() && !right.Nullable && !slices.Contains([]string{"range", "list", "tuple", "map", "string"}, right.Name) {
+			c.report(b.Operator, "Cannot test membership in a value of type "+right.String())
+		}
+	}
+	return &Type{Name: "bool"}, nil
+}
+
+func (c *TypeChecker) VisitTernary(t *Ternary) (any, error) {
+	c.typeOf(t.Left)
+	var center, right *Type
+	c.checkBranches([]func(){
+		func() { center = c.typeOf(t.Center) },
+		func() { right = c.typeOf(t.Right) },
+	}, true)
+	return joinTypes(center, right), nil
+}
+
+func (c *TypeChecker) VisitLogicalOperator(l *LogicalOperator) (any, error) {
+	left := c.typeOf(l.Left)
+	var right *Type
+	// The right operand may not be evaluated
+	c.checkBranches([]func(){
+		func() { right = c.typeOf(l.Right) },
+	}, false)
+	return joinTypes(left, right), nil
+}
+
+func (c *TypeChecker) VisitGrouping(g *Grouping) (any, error) {
+	return c.typeOf(g.Expression), nil
+}
+
+func (c *TypeChecker) VisitVarAssignment(v *VarAssignment) (any, error) {
+	value := c.typeOf(v.Expr)
+	target := c.assign(v.Token, value)
+	if !target.accepts(value) {
+		c.reportMismatch(v.Token, "Cannot assign "+value.String()+" to "+v.Token.Lexeme+" of type "+target.String())
+	}
+	return value, nil
+}
+
+func (c *TypeChecker) VisitFunction(f *Function) (any, error) {
+	callee := c.typeOf(f.Identifier)
+	// Checked once, their diagnostics reported once
+	args := make([]*Type, 0, len(*f.Args))
+	for _, arg := range *f.Args {
+		args = append(args, c.typeOf(arg))
+	}
+	if !callee.isAny() && callee.Name != "function" {
+		c.report(f.Token, "Cannot call a value of type "+callee.String())
+		return AnyType, nil
+	}
+	if callee.Function == nil {
+		return AnyType, nil
+	}
+
+	declaration := callee.Function
+	positional := 0
+	for argIdx, arg := range *f.Args {
+		switch arg := arg.(type) {
+		case *Spread:
+			// Can't tell which params the elements go to
+			positional = len(declaration.Params)
+		case *NamedArgument:
+			idx := slices.IndexFunc(declaration.Params, func(param *Token) bool { return param.Lexeme == arg.Name.Lexeme })
+			if idx != -1 {
+				c.checkArgument(declaration, idx, args[argIdx], arg.Name)
+			}
+		default:
+			if positional < len(declaration.Params) {
+				c.checkArgument(declaration, positional, args[argIdx], f.Token)
+			}
+			positional += 1
+		}
+	}
+	return c.annotated(declaration.ReturnType), nil
+}
+
+func (c *TypeChecker) checkArgument(declaration *FunctionDeclaration, idx int, got *Type, token *Token) {
+	expected := c.annotated(declaration.ParamTypes[idx])
+	if !expected.accepts(got) {
+		param := declaration.Params[idx].Lexeme
+		c.reportMismatch(token, "Argument "+param+" of "+declaration.Identifier.Lexeme+" must be "+expected.String()+" but got "+got.String())
+	}
+}
+
+func (c *TypeChecker) VisitNamedArgument(n *NamedArgument) (any, error) {
+	return c.typeOf(n.Value), nil
+}
+
+func (c *TypeChecker) VisitSpread(s *Spread) (any, error) {
+	return c.typeOf(s.Expr), nil
+}
+
+func (c *TypeChecker) VisitSpawn(s *Spawn) (any, error) {
+	c.typeOf(s.Call)
+	return &Type{Name: "task"}, nil
+}
+
+func (c *TypeChecker) VisitReceive(r *Receive) (any, error) {
+	c.typeOf(r.Channel)
+	return AnyType, nil
+}
+
+func (c *TypeChecker) VisitInterpolation(in *Interpolation) (any, error) {
+	for _, part := range in.Parts {
+		c.typeOf(part)
+	}
+	return &Type{Name: "string"}, nil
+}
+
+func (c *TypeChecker) VisitIndex(in *Index) (any, error) {
+	object := c.typeOf(in.Object)
+	c.typeOf(in.Index)
+	if object.isString() {
+		return &Type{Name: "string"}, nil
+	}
+	if !object.isAny() && !object.Nullable && !slices.Contains([]string{"list", "tuple", "map", "string"}, object.Name) {
+		c.report(in.Bracket, "Cannot index a value of type "+object.String())
+	}
+	return AnyType, nil
+}
+
+func (c *TypeChecker) VisitProperty(p *Property) (any, error) {
+	object := c.typeOf(p.Object)
+	if object.Enum != nil {
+		c.lookUpVariant(object.Enum, p.Name)
+		return AnyType, nil
+	}
+	if !object.isAny() && !object.Nullable {
+		c.report(p.Name, "Only enums and their values have properties, got "+object.String())
+	}
+	return AnyType, nil
+}
+
+// Reports variants missing from the enum, nil when unknown
+func (c *TypeChecker) lookUpVariant(enum *EnumDeclaration, name *Token) *VariantDeclaration {
+	for _, variant := range enum.Variants {
+		if variant.Name.Lexeme == name.Lexeme {
+			return variant
+		}
+	}
+	c.report(name, enum.Identifier.Lexeme+" has no variant "+name.Lexeme)
+	return nil
+}
+
+// Enum an expression or a pattern refers to, nil when unknown
+func (c *TypeChecker) enumOf(identifier *IdentifierExpr) *EnumDeclaration {
+	return c.lookUp(identifier.name.Lexeme).Enum
+}
+
+func (c *TypeChecker) VisitListLiteral(l *ListLiteral) (any, error) {
+	for _, element := range l.Elements {
+		c.typeOf(element)
+	}
+	return &Type{Name: "list"}, nil
+}
+
+func (c *TypeChecker) VisitTupleLiteral(t *TupleLiteral) (any, error) {
+	for _, element := range t.Elements {
+		c.typeOf(element)
+	}
+	return &Type{Name: "tuple"}, nil
+}
+
+func (c *TypeChecker) VisitRangeLiteral(r *RangeLiteral) (any, error) {
+	c.expectNumber(r.Operator, c.typeOf(r.Start))
+	c.expectNumber(r.Operator, c.typeOf(r.End))
+	if r.Step != nil {
+		c.expectNumber(r.Operator, c.typeOf(r.Step))
+	}
+	return &Type{Name: "range"}, nil
+}
+
+func (c *TypeChecker) VisitMapLiteral(m *MapLiteral) (any, error) {
+	for idx := range m.Keys {
+		c.typeOf(m.Keys[idx])
+		c.typeOf(m.Values[idx])
+	}
+	return &Type{Name: "map"}, nil
+}
+
+func (c *TypeChecker) VisitMatch(m *Match) (any, error) {
+	c.typeOf(m.Value)
+	var result *Type = nil
+	arms := []func(){}
+	for _, arm := range m.Arms {
+		arms = append(arms, func() {
+			c.beginScope()
+			defer c.endScope()
+			c.declarePattern(arm.Pattern)
+			if arm.Guard != nil {
+				c.typeOf(arm.Guard)
+			}
+			body := c.typeOf(arm.Body)
+			if result == nil {
+				result = body
+			} else {
+				result = joinTypes(result, body)
+			}
+		})
+	}
+	c.checkBranches(arms, true)
+
+	var enum *EnumDeclaration = nil
+	covered := map[string]bool{}
+	catchAll := false
+	for _, arm := range m.Arms {
+		c.checkEnumPatterns(arm.Pattern)
+		if arm.Guard == nil {
+			catchAll = c.coverVariants(arm.Pattern, &enum, covered) || catchAll
+		}
+	}
+	if enum != nil && !catchAll {
+		c.warnMissingVariants(m.Keyword, enum, covered)
+	}
+
+	if result == nil {
+		return AnyType, nil
+	}
+	return result, nil
+}
+
+// Reports unknown variants and associated values of the wrong count
+func (c *TypeChecker) checkEnumPatterns(pattern Pattern) {
+	switch pattern := pattern.(type) {
+	case *EnumPattern:
+		if enum := c.enumOf(pattern.Enum); enum != nil {
+			variant := c.lookUpVariant(enum, pattern.Variant)
+			if variant != nil && pattern.Values != nil && len(pattern.Values) != len(variant.Params) {
+				c.report(pattern.Variant, fmt.Sprintf("Variant %s has %d associated values but the pattern has %d", variant.Name.Lexeme, len(variant.Params), len(pattern.Values)))
+			}
+		}
+		for _, value := range pattern.Values {
+			c.checkEnumPatterns(value)
+		}
+	case *AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			c.checkEnumPatterns(alternative)
+		}
+	case *ListPattern:
+		for _, element := range pattern.Elements {
+			c.checkEnumPatterns(element)
+		}
+	case *TuplePattern:
+		for _, element := range pattern.Elements {
+			c.checkEnumPatterns(element)
+		}
+	case *MapPattern:
+		for _, value := range pattern.Values {
+			c.checkEnumPatterns(value)
+		}
+	}
+}
+
+// Marks the variants of enum the pattern matches whatever their associated
+// values, returns true when the pattern matches anything
+func (c *TypeChecker) coverVariants(pattern Pattern, enum **EnumDeclaration, covered map[string]bool) bool {
+	switch pattern := pattern.(type) {
+	case *WildcardPattern, *BindingPattern:
+		return true
+	case *AlternativePattern:
+		catchAll := false
+		for _, alternative := range pattern.Alternatives {
+			catchAll = c.coverVariants(alternative, enum, covered) || catchAll
+		}
+		return catchAll
+	case *EnumPattern:
+		patternEnum := c.enumOf(pattern.Enum)
+		if patternEnum == nil || (*enum != nil && *enum != patternEnum) {
+			return false
+		}
+		*enum = patternEnum
+		for _, value := range pattern.Values {
+			if !c.coverVariants(value, new(*EnumDeclaration), map[string]bool{}) {
+				return false
+			}
+		}
+		covered[pattern.Variant.Lexeme] = true
+	}
+	return false
+}
+
+// Warns, when inferring, about variants no case handles
+func (c *TypeChecker) warnMissingVariants(keyword *Token, enum *EnumDeclaration, covered map[string]bool) {
+	if !c.inference || c.silent {
+		return
+	}
+	missing := []string{}
+	for _, variant := range enum.Variants {
+		if !covered[variant.Name.Lexeme] {
+			missing = append(missing, enum.Identifier.Lexeme+"."+variant.Name.Lexeme)
+		}
+	}
+	if len(missing) > 0 {
+		c.Lox.Warn(keyword, keyword.Lexeme+" doesn't cover "+strings.Join(missing, ", "))
+	}
+}
+
+// Pattern bindings are dynamic
+func (c *TypeChecker) declarePattern(pattern Pattern) {
+	for _, name := range pattern.names() {
+		c.declare(name, AnyType)
+	}
+}
+
+func (c *TypeChecker) VisitDestructuringAssignment(d *DestructuringAssignment) (any, error) {
+	c.typeOf(d.Expr)
+	for _, assignment := range d.Assignments {
+		if target := c.assign(assignment.Token, AnyType); !target.isAny() {
+			c.reportMismatch(assignment.Token, "Cannot destructure into "+assignment.Token.Lexeme+" of type "+target.String())
+		}
+	}
+	return AnyType, nil
+}
+
+func (c *TypeChecker) VisitExpressionStatement(e *ExpressionStatement) (any, error) {
+	c.typeOf(e.Expr)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitPrintStatement(p *PrintStatement) error {
+	c.typeOf(p.Expr)
+	return nil
+}
+
+func (c *TypeChecker) VisitVarDeclaration(v *VarDeclaration) (any, error) {
+	value := c.typeOf(v.Expr)
+	declared := c.annotated(v.Type)
+	if !declared.accepts(value) {
+		c.reportMismatch(v.Identifier, "Cannot initialize "+v.Identifier.Lexeme+" of type "+declared.String()+" with "+value.String())
+	}
+	if c.inference {
+		declared = value
+	}
+	c.declare(v.Identifier, declared)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitDestructuringDeclaration(d *DestructuringDeclaration) (any, error) {
+	c.typeOf(d.Expr)
+	c.declarePattern(d.Pattern)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitBlockStatement(b *BlockStatement) (any, error) {
+	c.beginScope()
+	defer c.endScope()
+	c.checkStmts(b.Statements)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitIfStatement(i *IfStatement) (any, error) {
+	c.typeOf(i.Expr)
+	branches := []func(){func() { c.checkStmt(i.IfStmt) }}
+	if i.ElseStmt != nil {
+		branches = append(branches, func() { c.checkStmt(i.ElseStmt) })
+	}
+	c.checkBranches(branches, i.ElseStmt != nil)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitWhileStatement(w *WhileStatement) (any, error) {
+	c.checkLoop(func() {
+		if w.DoWhile {
+			c.checkStmt(w.Stmt)
+			c.typeOf(w.Expr)
+		} else {
+			c.typeOf(w.Expr)
+			c.checkStmt(w.Stmt)
+		}
+	})
+	if w.Else != nil {
+		c.checkStmt(w.Else)
+	}
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitForInStatement(f *ForInStatement) (any, error) {
+	c.typeOf(f.Iterable)
+	c.checkLoop(func() {
+		c.beginScope()
+		defer c.endScope()
+		for _, name := range f.Names {
+			c.declare(name, AnyType)
+		}
+		c.checkStmt(f.Stmt)
+	})
+	if f.Else != nil {
+		c.checkStmt(f.Else)
+	}
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitEnumDeclaration(e *EnumDeclaration) (any, error) {
+	c.declare(e.Identifier, &Type{Name: "enum", Enum: e})
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitFunctionDeclaration(f *FunctionDeclaration) (any, error) {
+	// Declared first so that recursive calls are checked
+	c.declare(f.Identifier, &Type{Name: "function", Function: f})
+
+	prevReturnType, prevFunction := c.returnType, c.function
+	defer func() {
+		c.returnType, c.function = prevReturnType, prevFunction
+	}()
+	c.returnType, c.function = nil, f
+	if f.ReturnType != nil {
+		c.returnType = c.annotated(f.ReturnType)
+	}
+
+	// The body runs later, its assignments don't change the current flow
+	before := c.snapshot()
+	defer c.restore(before)
+
+	c.beginScope()
+	defer c.endScope()
+	for idx, param := range f.Params {
+		declared := c.annotated(f.ParamTypes[idx])
+		if f.Defaults[idx] != nil {
+			if value := c.typeOf(f.Defaults[idx]); !declared.accepts(value) {
+				c.reportMismatch(param, "Default value of "+param.Lexeme+" must be "+declared.String()+" but got "+value.String())
+			}
+		}
+		c.declare(param, declared)
+	}
+	if f.Rest != nil {
+		c.declare(f.Rest, &Type{Name: "list"})
+	}
+	c.checkStmts(f.Stmts)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitReturnStatement(r *ReturnStatement) (any, error) {
+	value := &Type{Name: "nil"}
+	if r.Expr != nil {
+		value = c.typeOf(r.Expr)
+	}
+	if c.returnType != nil && !c.function.Generator && !c.returnType.accepts(value) {
+		c.reportMismatch(r.Token, c.function.Identifier.Lexeme+" must return "+c.returnType.String()+" but returns "+value.String())
+	}
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitYieldStatement(y *YieldStatement) (any, error) {
+	c.typeOf(y.Expr)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitSwitchStatement(s *SwitchStatement) (any, error) {
+	c.typeOf(s.Value)
+	cases := []func(){}
+	hasDefault := false
+	for _, switchCase := range s.Cases {
+		for _, label := range switchCase.Labels {
+			c.typeOf(label)
+		}
+		hasDefault = hasDefault || switchCase.Labels == nil
+		cases = append(cases, func() { c.checkStmt(switchCase.Body) })
+	}
+	c.checkBranches(cases, hasDefault)
+
+	var enum *EnumDeclaration = nil
+	covered := map[string]bool{}
+	for _, switchCase := range s.Cases {
+		for _, label := range switchCase.Labels {
+			property, ok := label.(*Property)
+			if !ok {
+				continue
+			}
+			identifier, ok := property.Object.(*IdentifierExpr)
+			if !ok {
+				continue
+			}
+			if labelEnum := c.enumOf(identifier); labelEnum != nil && (enum == nil || enum == labelEnum) {
+				enum = labelEnum
+				covered[property.Name.Lexeme] = true
+			}
+		}
+	}
+	if enum != nil && !hasDefault {
+		c.warnMissingVariants(s.Keyword, enum, covered)
+	}
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitSendStatement(s *SendStatement) (any, error) {
+	c.typeOf(s.Channel)
+	c.typeOf(s.Value)
+	return nil, nil
+}
+
+func (c *TypeChecker) VisitSelectStatement(s *SelectStatement) (any, error) {
+	cases := []func(){}
+	for _, selectCase := range s.Cases {
+		if selectCase.Channel != nil {
+			c.typeOf(selectCase.Channel)
+		}
+		if selectCase.Value != nil {
+			c.typeOf(selectCase.Value)
+		}
+		cases = append(cases, func() {
+			c.beginScope()
+			defer c.endScope()
+			if selectCase.Name != nil {
+				c.declare(selectCase.Name, AnyType)
+			}
+			c.checkStmt(selectCase.Body)
+		})
+	}
+	c.checkBranches(cases, true)
+	return nil, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type List struct {
+	Elements []any
+}
+
+func CreateList(elements []any) *List {
+	return &List{
+		Elements: elements,
+	}
+}
+
+// Immutable, so tuples can be map keys
+type Tuple struct {
+	Elements []any
+}
+
+func CreateTuple(elements []any) *Tuple {
+	return &Tuple{
+		Elements: elements,
+	}
+}
+
+// Keeps its entries in insertion order
+type Map struct {
+	// Normalized keys, see `mapKey`
+	order   []any
+	entries map[any]*MapEntry
+}
+
+type MapEntry struct {
+	Key   any
+	Value any
+}
+
+func CreateMap() *Map {
+	return &Map{
+		order:   make([]any, 0),
+		entries: make(map[any]*MapEntry),
+	}
+}
+
+// Numbers that compare equal must be the same key, so 1, 1.0 and 1n all
+// normalize to int64(1) and other numbers to their exact fraction.
+// Lists and maps are mutable and can't be keys.
+type numberKey struct {
+	fraction string
+}
+
+// Tuples are keys when all their elements are
+type tupleKey struct {
+	elements string
+}
+
+// Enum values are keys when all their associated values are, equal values
+// of the same variant being the same key
+type enumKey struct {
+	variant *EnumVariant
+	values  string
+}
+
+// Normalized elements joined in a string, false if one can't be a key
+func elementsKey(elements []any) (string, bool) {
+	normalizedElements := make([]string, 0, len(elements))
+	for _, element := range elements {
+		normalized, ok := mapKey(element)
+		if !ok {
+			return "", false
+		}
+		normalizedElements = append(normalizedElements, fmt.Sprintf("%T %#v", normalized, normalized))
+	}
+	return strings.Join(normalizedElements, ", "), true
+}
+
+func mapKey(key any) (any, bool) {
+	switch key.(type) {
+	case *List, *Map:
+		return nil, false
+	case *Range:
+		return *key.(*Range), true
+	case *Tuple:
+		elements, ok := elementsKey(key.(*Tuple).Elements)
+		if !ok {
+			return nil, false
+		}
+		return tupleKey{elements}, true
+	case *EnumValue:
+		value := key.(*EnumValue)
+		values, ok := elementsKey(value.Values)
+		if !ok {
+			return nil, false
+		}
+		return enumKey{value.Variant, values}, true
+	case *big.Int, *Decimal, float64:
+		r := toRat(key)
+		if r == nil {
+			return numberKey{stringify(key)}, true
+		}
+		if r.IsInt() && r.Num().IsInt64() {
+			return r.Num().Int64(), true
+		}
+		return numberKey{r.RatString()}, true
+	}
+	return key, true
+}
+
+func (m *Map) Get(key any) (any, bool) {
+	normalized, ok := mapKey(key)
+	if !ok {
+		return nil, false
+	}
+	entry, found := m.entries[normalized]
+	if !found {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+// Returns false when the key can't be used in a map
+func (m *Map) Set(key any, value any) bool {
+	normalized, ok := mapKey(key)
+	if !ok {
+		return false
+	}
+	if entry, found := m.entries[normalized]; found {
+		entry.Value = value
+		return true
+	}
+	m.order = append(m.order, normalized)
+	m.entries[normalized] = &MapEntry{Key: key, Value: value}
+	return true
+}
+
+func (m *Map) Len() int {
+	return len(m.order)
+}
+
+func (m *Map) Entries() []*MapEntry {
+	entries := make([]*MapEntry, 0, len(m.order))
+	for _, key := range m.order {
+		entries = append(entries, m.entries[key])
+	}
+	return entries
+}
+
+func (l *List) String() string {
+	elements := make([]string, 0, len(l.Elements))
+	for _, element := range l.Elements {
+		elements = append(elements, stringifyNested(element))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (t *Tuple) String() string {
+	elements := make([]string, 0, len(t.Elements))
+	for _, element := range t.Elements {
+		elements = append(elements, stringifyNested(element))
+	}
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+func (m *Map) String() string {
+	entries := make([]string, 0, m.Len())
+	for _, entry := range m.Entries() {
+		entries = append(entries, stringifyNested(entry.Key)+": "+stringifyNested(entry.Value))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// Strings inside collections are quoted
+func stringifyNested(value any) string {
+	if str, ok := value.(string); ok {
+		return strconv.Quote(str)
+	}
+	return stringify(value)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+)
+
+// `spawn f(x)` evaluates the callee and the arguments, then runs the call on
+// a goroutine with its own interpreter, so that each task has its own
+// current environment. Tasks share the globals and the environments their
+// functions close over.
+//
+// Channels carry values between tasks :
+//   - `channel()` is unbuffered, `channel(n)` buffers up to n values
+//   - `ch <- value;` sends, `<-ch` receives, nil once the channel is closed
+//     and drained
+//   - `close(ch)` closes, sending on a closed channel is a runtime error
+//   - `for (v in ch)` receives until the channel is closed
+//
+// The script ends with its main task, `wait(task)` returns the result of a
+// task or raises its error.
+type Task struct {
+	done  chan struct{}
+	value any
+	err   error
+	name  string
+}
+
+func (t *Task) wait() (any, error) {
+	<-t.done
+	return t.value, t.err
+}
+
+func (t *Task) String() string {
+	return "<task " + t.name + ">"
+}
+
+type Channel struct {
+	ch chan any
+	// Guards closed, Go panics when closing twice
+	mutex  sync.Mutex
+	closed bool
+}
+
+func CreateChannel(capacity int) *Channel {
+	return &Channel{
+		ch: make(chan any, capacity),
+	}
+}
+
+// Returns false when the channel is closed, possibly while waiting
+func (c *Channel) send(value any) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+	c.ch <- value
+	return true
+}
+
+func (c *Channel) close() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	close(c.ch)
+	return true
+}
+
+func (c *Channel) next(i *Interpreter) (any, bool, error) {
+	value, ok := <-c.ch
+	return value, ok, nil
+}
+
+func (c *Channel) String() string {
+	return "<channel>"
+}
+
+func (i *Interpreter) VisitSpawn(s *Spawn) (any, error) {
+	_callee, err := s.Call.Identifier.accept(i)
+	if err != nil {
+		return nil, err
+	}
+	callee, ok := _callee.(Callee)
+	if !ok {
+		return nil, CreateRuntimeError(s.Call.Token, "Identifier `"+s.Call.Token.Lexeme+"` is not a function")
+	}
+
+	// Arguments are evaluated by the spawning task and handed over as values
+	args := make([]Expression, 0, len(*s.Call.Args))
+	for _, arg := range *s.Call.Args {
+		switch arg := arg.(type) {
+		case *NamedArgument:
+			val, err := i.evaluate(arg.Value)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, CreateNamedArgument(arg.Name, CreateLiteral(val)))
+		case *Spread:
+			val, err := i.evaluate(arg.Expr)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, CreateSpread(arg.Token, CreateLiteral(val)))
+		default:
+			val, err := i.evaluate(arg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, CreateLiteral(val))
+		}
+	}
+
+	task := &Task{done: make(chan struct{}), name: s.Call.Token.Lexeme}
+	interpreter := i.fork(i.Environment)
+	go func() {
+		defer close(task.done)
+		task.value, task.err = callee.call(interpreter, s.Call.Token, &args)
+	}()
+	return task, nil
+}
+
+func (i *Interpreter) evaluateChannel(token *Token, expr Expression) (*Channel, error) {
+	value, err := i.evaluate(expr)
+	if err != nil {
+		return nil, err
+	}
+	channel, ok := value.(*Channel)
+	if !ok {
+		return nil, CreateRuntimeError(token, "Expected a channel but got "+stringifyNested(value))
+	}
+	return channel, nil
+}
+
+func (i *Interpreter) VisitReceive(r *Receive) (any, error) {
+	channel, err := i.evaluateChannel(r.Arrow, r.Channel)
+	if err != nil {
+		return nil, err
+	}
+	return <-channel.ch, nil
+}
+
+func (i *Interpreter) VisitSendStatement(s *SendStatement) (any, error) {
+	channel, err := i.evaluateChannel(s.Arrow, s.Channel)
+	if err != nil {
+		return nil, err
+	}
+	value, err := i.evaluate(s.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !channel.send(value) {
+		return nil, CreateRuntimeError(s.Arrow, "Cannot send on a closed channel")
+	}
+	return nil, nil
+}
+
+// Evaluates every channel and sent value in order, then runs the first case
+// able to proceed, waiting if none can and there is no default case
+func (i *Interpreter) VisitSelectStatement(s *SelectStatement) (any, error) {
+	cases := make([]reflect.SelectCase, 0, len(s.Cases))
+	for _, selectCase := range s.Cases {
+		if selectCase.Channel == nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+			continue
+		}
+		channel, err := i.evaluateChannel(selectCase.Token, selectCase.Channel)
+		if err != nil {
+			return nil, err
+		}
+		if selectCase.Value == nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel.ch)})
+			continue
+		}
+		value, err := i.evaluate(selectCase.Value)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(channel.ch), Send: reflect.ValueOf(&value).Elem()})
+	}
+
+	chosen, received, ok, closed := i.selectCases(cases)
+	if closed {
+		return nil, CreateRuntimeError(s.Keyword, "Cannot send on a closed channel")
+	}
+	selectCase := s.Cases[chosen]
+
+	prevEnv := i.Environment
+	defer func() {
+		i.Environment = prevEnv
+	}()
+	i.Environment = CreateEnvironment(prevEnv, i)
+	if selectCase.Name != nil {
+		var value any = nil
+		if ok {
+			value = received.Interface()
+		}
+		i.Environment.Set(selectCase.Name.Lexeme, value)
+	}
+
+	_, err := selectCase.Body.accept(i)
+	if err == BreakStmtErr {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// Reports a send on a closed channel instead of panicking
+func (i *Interpreter) selectCases(cases []reflect.SelectCase) (chosen int, received reflect.Value, ok bool, closed bool) {
+	defer func() {
+		if recover() != nil {
+			closed = true
+		}
+	}()
+	chosen, received, ok = reflect.Select(cases)
+	return chosen, received, ok, false
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// `enum Color { Red, Green, Blue }` binds Color to a namespace of variants.
+// Plain variants are values, `Color.Red`, equal only to themselves. Variants
+// with associated values, `enum Option { Some(value), None }`, are
+// constructors : `Option.Some(1)` builds a value equal to any other
+// `Option.Some(1)`, whose associated values are read with `.value` or with
+// a pattern.
+//
+// Iterating over an enum yields its variants in declaration order, the
+// constructors standing for the variants with associated values.
+type Enum struct {
+	Name     string
+	Variants []*EnumVariant
+}
+
+type EnumVariant struct {
+	Enum   *Enum
+	Name   string
+	Params []*Token
+	// The value of a plain variant, nil for a constructor
+	value *EnumValue
+}
+
+type EnumValue struct {
+	Variant *EnumVariant
+	Values  []any
+}
+
+func CreateEnum(declaration *EnumDeclaration) *Enum {
+	enum := &Enum{Name: declaration.Identifier.Lexeme}
+	for _, decl := range declaration.Variants {
+		variant := &EnumVariant{Enum: enum, Name: decl.Name.Lexeme, Params: decl.Params}
+		if decl.Params == nil {
+			variant.value = &EnumValue{Variant: variant}
+		}
+		enum.Variants = append(enum.Variants, variant)
+	}
+	return enum
+}
+
+func (e *Enum) variant(name string) *EnumVariant {
+	for _, variant := range e.Variants {
+		if variant.Name == name {
+			return variant
+		}
+	}
+	return nil
+}
+
+// The value of a plain variant, the constructor of the others
+func (v *EnumVariant) member() any {
+	if v.value != nil {
+		return v.value
+	}
+	return v
+}
+
+func (e *Enum) members() []any {
+	members := make([]any, 0, len(e.Variants))
+	for _, variant := range e.Variants {
+		members = append(members, variant.member())
+	}
+	return members
+}
+
+func (e *Enum) String() string {
+	return "<enum " + e.Name + ">"
+}
+
+func (v *EnumVariant) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(v, token, args)
+	if err != nil {
+		return nil, err
+	}
+	return &EnumValue{Variant: v, Values: argsVal}, nil
+}
+
+func (v *EnumVariant) arity() (int, int) {
+	return len(v.Params), len(v.Params)
+}
+
+func (v *EnumVariant) toString() string {
+	return "<variant " + v.Enum.Name + "." + v.Name + ">"
+}
+
+func (e *EnumValue) String() string {
+	name := e.Variant.Enum.Name + "." + e.Variant.Name
+	if e.Variant.value != nil {
+		return name
+	}
+	values := make([]string, 0, len(e.Values))
+	for _, value := range e.Values {
+		values = append(values, stringifyNested(value))
+	}
+	return name + "(" + strings.Join(values, ", ") + ")"
+}
+
+func (i *Interpreter) VisitEnumDeclaration(e *EnumDeclaration) (any, error) {
+	name := e.Identifier.Lexeme
+	if _, err := i.Environment.GetCurrentBlock(name); err == nil {
+		return nil, CreateRuntimeError(e.Identifier, "Redeclaration of name "+name)
+	}
+	i.Environment.SetConstant(name, CreateEnum(e))
+	return nil, nil
+}
+
+func (i *Interpreter) VisitProperty(p *Property) (any, error) {
+	object, err := i.evaluate(p.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *Enum:
+		variant := object.variant(p.Name.Lexeme)
+		if variant == nil {
+			return nil, CreateRuntimeError(p.Name, object.Name+" has no variant "+p.Name.Lexeme)
+		}
+		return variant.member(), nil
+	case *EnumValue:
+		for idx, param := range object.Variant.Params {
+			if param.Lexeme == p.Name.Lexeme {
+				return object.Values[idx], nil
+			}
+		}
+		return nil, CreateRuntimeError(p.Name, stringify(object)+" has no associated value "+p.Name.Lexeme)
+	}
+	return nil, CreateRuntimeError(p.Name, "Only enums and their values have properties, got "+stringifyNested(object))
+}
+
+// Variant named by an enum pattern, checking its associated values
+func (i *Interpreter) lookUpVariant(pattern *EnumPattern) (*EnumVariant, error) {
+	value, err := i.evaluate(pattern.Enum)
+	if err != nil {
+		return nil, err
+	}
+	enum, ok := value.(*Enum)
+	if !ok {
+		return nil, CreateRuntimeError(pattern.Enum.name, pattern.Enum.name.Lexeme+" is not an enum")
+	}
+	variant := enum.variant(pattern.Variant.Lexeme)
+	if variant == nil {
+		return nil, CreateRuntimeError(pattern.Variant, enum.Name+" has no variant "+pattern.Variant.Lexeme)
+	}
+	if pattern.Values != nil && len(pattern.Values) != len(variant.Params) {
+		return nil, CreateRuntimeError(pattern.Variant, fmt.Sprintf("Variant %s has %d associated values but the pattern has %d", variant.Name, len(variant.Params), len(pattern.Values)))
+	}
+	return variant, nil
+}
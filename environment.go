@@ -2,41 +2,87 @@ package main
 
 import (
 	"errors"
+	"sync"
+	"weak"
 )
 
 type Environment struct {
 	Identifiers      []*Identifier
 	PrevEnv     *Environment
 	Interpreter *Interpreter
+	// Spawned tasks may share environments
+	mutex sync.RWMutex
+	// Encloses the scope of a generator call instead of PrevEnv, so that the
+	// suspended body doesn't keep the generator alive through the scope
+	// holding it. The generator and the functions declared in the body keep
+	// the enclosing scope alive instead
+	closure weak.Pointer[Environment]
 }
 
 type Identifier struct {
-	Value any
-	Name  string
+	Value    any
+	Name     string
+	Constant bool
 }
 
 func (env *Environment) lookUpVariable(name string, expr Expression) (any, error) {
-	local, ok := env.Interpreter.Locals[expr]
+	local, ok := env.Interpreter.local(expr)
 	if !ok {
 		return env.findInGlobal(name)
 	}
 
-	currEnv := env.GetAt(local.Distance)
+	currEnv, err := env.GetAt(local.Distance)
+	if err != nil {
+		return nil, err
+	}
+	currEnv.mutex.RLock()
+	defer currEnv.mutex.RUnlock()
 	result := currEnv.Identifiers[local.Index]
 
 	return result.Value, nil
 }
 
-func (env *Environment) GetAt(dist int) *Environment {
+// A scope linked through closure is alive as long as whatever may still run
+// in it holds it : the Generator, the functions declared in its body
+// (Closure.scopes) and the generator's next while the body runs. Anything
+// else capturing such an environment must keep its weak scopes too, or
+// lookups fail here once they are collected
+func (env *Environment) GetAt(dist int) (*Environment, error) {
 	curr := env
 	for i := 0; i < dist; i += 1 {
-		curr = curr.PrevEnv
+		curr = curr.parent()
+		if curr == nil {
+			return nil, errors.New("The scope of the variable was collected")
+		}
+	}
+	return curr, nil
+}
+
+func (env *Environment) parent() *Environment {
+	if env.PrevEnv != nil {
+		return env.PrevEnv
+	}
+	return env.closure.Value()
+}
+
+// Scopes enclosing env only weakly, see closure
+func (env *Environment) weakScopes() []*Environment {
+	var scopes []*Environment
+	for curr := env; curr != nil; curr = curr.parent() {
+		if curr.PrevEnv == nil {
+			if scope := curr.closure.Value(); scope != nil {
+				scopes = append(scopes, scope)
+			}
+		}
 	}
-	return curr
+	return scopes
 }
 
 func (env *Environment) findInGlobal(name string) (any, error) {
-	for _, val := range env.Interpreter.Globals.Identifiers {
+	globals := env.Interpreter.Globals
+	globals.mutex.RLock()
+	defer globals.mutex.RUnlock()
+	for _, val := range globals.Identifiers {
 		if val.Name == name {
 			return val.Value, nil
 		}
@@ -55,6 +101,8 @@ func (env *Environment) findByName(name string) (*Identifier, bool) {
 }
 
 func (env *Environment) GetCurrentBlock(name string) (any, error) {
+	env.mutex.RLock()
+	defer env.mutex.RUnlock()
 
 	res, found := env.findByName(name)
 	if !found {
@@ -64,11 +112,24 @@ func (env *Environment) GetCurrentBlock(name string) (any, error) {
 }
 
 func (env *Environment) Set(name string, value any) {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
 	env.Identifiers = append(env.Identifiers, &Identifier{Value: value, Name: name})
 }
 
-func (env *Environment) AssignAt(distance int, token Token, value any) {
-	targetEnv := env.GetAt(distance)
+func (env *Environment) SetConstant(name string, value any) {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+	env.Identifiers = append(env.Identifiers, &Identifier{Value: value, Name: name, Constant: true})
+}
+
+func (env *Environment) AssignAt(distance int, token Token, value any) error {
+	targetEnv, err := env.GetAt(distance)
+	if err != nil {
+		return err
+	}
+	targetEnv.mutex.Lock()
+	defer targetEnv.mutex.Unlock()
 
 	val, found := targetEnv.findByName(token.Lexeme)
 	if !found {
@@ -76,6 +137,7 @@ func (env *Environment) AssignAt(distance int, token Token, value any) {
 	}
 
 	val.Value = value
+	return nil
 }
 
 func CreateEnvironment(prevEnv *Environment, interpreter *Interpreter) *Environment {
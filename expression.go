@@ -149,3 +149,218 @@ func CreateFunctionCall(identifier Expression, args *[]Expression, token *Token)
 		Token:      token,
 	}
 }
+
+// f(1, b: 2) : only valid as a call argument
+type NamedArgument struct {
+	Name  *Token
+	Value Expression
+}
+
+func (n *NamedArgument) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitNamedArgument(n)
+}
+
+func CreateNamedArgument(name *Token, value Expression) *NamedArgument {
+	return &NamedArgument{
+		Name:  name,
+		Value: value,
+	}
+}
+
+// f(...xs), [0, ...xs] : only valid as a call argument or list element
+type Spread struct {
+	Token *Token
+	Expr  Expression
+}
+
+func (s *Spread) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitSpread(s)
+}
+
+func CreateSpread(token *Token, expr Expression) *Spread {
+	return &Spread{
+		Token: token,
+		Expr:  expr,
+	}
+}
+
+// spawn f(x) : runs the call on another task
+type Spawn struct {
+	Keyword *Token
+	Call    *Function
+}
+
+func (s *Spawn) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitSpawn(s)
+}
+
+func CreateSpawn(keyword *Token, call *Function) *Spawn {
+	return &Spawn{
+		Keyword: keyword,
+		Call:    call,
+	}
+}
+
+// <-channel
+type Receive struct {
+	Arrow   *Token
+	Channel Expression
+}
+
+func (r *Receive) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitReceive(r)
+}
+
+func CreateReceive(arrow *Token, channel Expression) *Receive {
+	return &Receive{
+		Arrow:   arrow,
+		Channel: channel,
+	}
+}
+
+// "a ${b} c" : string segments are literals, the rest are the
+// interpolated expressions
+type Interpolation struct {
+	Parts []Expression
+}
+
+func (i *Interpolation) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitInterpolation(i)
+}
+
+func CreateInterpolation(parts []Expression) *Interpolation {
+	return &Interpolation{
+		Parts: parts,
+	}
+}
+
+// object[index]
+type Index struct {
+	Object  Expression
+	Index   Expression
+	Bracket *Token
+}
+
+func (i *Index) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitIndex(i)
+}
+
+func CreateIndex(object Expression, index Expression, bracket *Token) *Index {
+	return &Index{
+		Object:  object,
+		Index:   index,
+		Bracket: bracket,
+	}
+}
+
+// object.name
+type Property struct {
+	Object Expression
+	Name   *Token
+}
+
+func (p *Property) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitProperty(p)
+}
+
+func CreateProperty(object Expression, name *Token) *Property {
+	return &Property{
+		Object: object,
+		Name:   name,
+	}
+}
+
+// [1, 2, 3]
+type ListLiteral struct {
+	Elements []Expression
+}
+
+func (l *ListLiteral) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitListLiteral(l)
+}
+
+func CreateListLiteral(elements []Expression) *ListLiteral {
+	return &ListLiteral{
+		Elements: elements,
+	}
+}
+
+// 1, 2 or (1, 2), (1,) and () for one and no element
+type TupleLiteral struct {
+	Elements []Expression
+}
+
+func (t *TupleLiteral) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitTupleLiteral(t)
+}
+
+func CreateTupleLiteral(elements []Expression) *TupleLiteral {
+	return &TupleLiteral{
+		Elements: elements,
+	}
+}
+
+// a..b, a..=b, a..b step s
+type RangeLiteral struct {
+	Start    Expression
+	End      Expression
+	Operator *Token
+	// nil without `step`
+	Step Expression
+}
+
+func (r *RangeLiteral) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitRangeLiteral(r)
+}
+
+func CreateRangeLiteral(start Expression, operator *Token, end Expression, step Expression) *RangeLiteral {
+	return &RangeLiteral{
+		Start:    start,
+		End:      end,
+		Operator: operator,
+		Step:     step,
+	}
+}
+
+// {name: "wes", "key": 1, 2: 3}
+type MapLiteral struct {
+	Keys   []Expression
+	Values []Expression
+	Brace  *Token
+}
+
+func (m *MapLiteral) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitMapLiteral(m)
+}
+
+func CreateMapLiteral(keys []Expression, values []Expression, brace *Token) *MapLiteral {
+	return &MapLiteral{
+		Keys:   keys,
+		Values: values,
+		Brace:  brace,
+	}
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+}
+
+type Match struct {
+	Keyword *Token
+	Value   Expression
+	Arms    []*MatchArm
+}
+
+func (m *Match) accept(v ExpressionVisitor) (any, error) {
+	return v.VisitMatch(m)
+}
+
+func CreateMatch(keyword *Token, value Expression, arms []*MatchArm) *Match {
+	return &Match{
+		Keyword: keyword,
+		Value:   value,
+		Arms:    arms,
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// Calling a function containing `yield` returns a generator, an iterator
+// running the body up to the next `yield` each time a value is requested.
+//
+// The body runs on its own goroutine with its own interpreter, handing
+// control back and forth with the caller so that only one of them runs at a
+// time. The goroutine is only started by the first request, and is stopped
+// when a loop breaks out of the generator or when the generator is garbage
+// collected, so abandoned generators don't leak.
+type Generator struct {
+	state *generatorState
+	name  string
+	// The scope enclosing the body, which the body only links to weakly as
+	// it may hold the generator
+	scope *Environment
+}
+
+// Everything the body goroutine uses, kept apart from the Generator so that
+// the goroutine doesn't keep it alive. Nothing reachable from it may lead
+// back to the Generator
+type generatorState struct {
+	interpreter *Interpreter
+	body        *FunctionDeclaration
+	// true to run up to the next yield, false to stop
+	resume  chan bool
+	results chan generatorResult
+	started bool
+	done    bool
+	// Tasks may share a generator
+	mutex sync.Mutex
+}
+
+type generatorResult struct {
+	value any
+	// The body finished, either normally or with err
+	done bool
+	err  error
+}
+
+// Unwinds the body of a generator that is stopped while suspended
+var GeneratorClosedErr = errors.New("GeneratorClosed")
+
+func CreateGenerator(interpreter *Interpreter, body *FunctionDeclaration) *Generator {
+	state := &generatorState{
+		interpreter: interpreter,
+		body:        body,
+		resume:      make(chan bool),
+		results:     make(chan generatorResult),
+	}
+	interpreter.generator = state
+
+	env := interpreter.Environment
+	generator := &Generator{state: state, name: body.Identifier.Lexeme, scope: env.PrevEnv}
+	env.closure = weak.Make(env.PrevEnv)
+	env.PrevEnv = nil
+	// Unlike a finalizer, runs even though the generator may be part of a
+	// cycle through its scope
+	runtime.AddCleanup(generator, (*generatorState).close, state)
+	return generator
+}
+
+func (g *Generator) next(i *Interpreter) (any, bool, error) {
+	state := g.state
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	if state.done {
+		return nil, false, nil
+	}
+	if !state.started {
+		state.started = true
+		go state.run()
+	}
+
+	state.resume <- true
+	result := <-state.results
+	// The body reaches its scope through the generator
+	runtime.KeepAlive(g)
+	if result.done {
+		state.done = true
+		return nil, false, result.err
+	}
+	return result.value, true, nil
+}
+
+func (g *Generator) close() {
+	g.state.close()
+}
+
+func (s *generatorState) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started && !s.done {
+		// The body is waiting in a yield
+		s.resume <- false
+	}
+	s.done = true
+}
+
+func (g *Generator) String() string {
+	return "<generator " + g.name + ">"
+}
+
+func (s *generatorState) run() {
+	if !<-s.resume {
+		return
+	}
+	_, err := s.body.run(s.interpreter)
+	if err == GeneratorClosedErr {
+		return
+	}
+	s.results <- generatorResult{done: true, err: err}
+}
+
+func (i *Interpreter) VisitYieldStatement(y *YieldStatement) (any, error) {
+	value, err := i.evaluate(y.Expr)
+	if err != nil {
+		return nil, err
+	}
+	if i.generator == nil {
+		return nil, CreateRuntimeError(y.Keyword, "Can't yield outside of a generator")
+	}
+
+	i.generator.results <- generatorResult{value: value}
+	if !<-i.generator.resume {
+		return nil, GeneratorClosedErr
+	}
+	return nil, nil
+}
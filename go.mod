@@ -1,3 +1,5 @@
 module wesly/jlox
 
-go 1.23.5
+// 1.24 for weak pointers and runtime.AddCleanup, which let abandoned
+// generators stored in their own closure scope stop, see generator.go
+go 1.24
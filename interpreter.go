@@ -3,7 +3,9 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"sync"
 )
 
 type ExpressionVisitor interface {
@@ -16,12 +18,29 @@ type ExpressionVisitor interface {
 	VisitGrouping(g *Grouping) (any, error)
 	VisitVarAssignment(v *VarAssignment) (any, error)
 	VisitFunction(f *Function) (any, error)
+	VisitNamedArgument(n *NamedArgument) (any, error)
+	VisitSpread(s *Spread) (any, error)
+	VisitSpawn(s *Spawn) (any, error)
+	VisitReceive(r *Receive) (any, error)
+	VisitInterpolation(in *Interpolation) (any, error)
+	VisitIndex(in *Index) (any, error)
+	VisitProperty(p *Property) (any, error)
+	VisitListLiteral(l *ListLiteral) (any, error)
+	VisitTupleLiteral(t *TupleLiteral) (any, error)
+	VisitRangeLiteral(r *RangeLiteral) (any, error)
+	VisitMapLiteral(m *MapLiteral) (any, error)
+	VisitMatch(m *Match) (any, error)
+	VisitDestructuringAssignment(d *DestructuringAssignment) (any, error)
 }
 
 type Interpreter struct {
 	Environment *Environment
 	Locals      map[Expression]*Local
 	Globals     *Environment
+	// Set while running the body of a generator
+	generator *generatorState
+	// Guards Locals, which the resolver fills while spawned tasks run
+	localsMutex *sync.RWMutex
 }
 
 type Local struct {
@@ -35,6 +54,7 @@ func CreateAndSetupInterpreter() *Interpreter {
 		Environment: globalInterpreter,
 		Locals:      make(map[Expression]*Local),
 		Globals:     globalInterpreter,
+		localsMutex: &sync.RWMutex{},
 	}
 	globalInterpreter.Interpreter = interpreter
 	SetupInterpreter(interpreter)
@@ -42,6 +62,30 @@ func CreateAndSetupInterpreter() *Interpreter {
 	return interpreter
 }
 
+// Interpreter sharing the globals and resolution of i but having its own
+// current environment, to run code on another goroutine
+func (i *Interpreter) fork(env *Environment) *Interpreter {
+	return &Interpreter{
+		Environment: env,
+		Locals:      i.Locals,
+		Globals:     i.Globals,
+		localsMutex: i.localsMutex,
+	}
+}
+
+func (i *Interpreter) local(expr Expression) (*Local, bool) {
+	i.localsMutex.RLock()
+	defer i.localsMutex.RUnlock()
+	local, found := i.Locals[expr]
+	return local, found
+}
+
+func (i *Interpreter) setLocal(expr Expression, local *Local) {
+	i.localsMutex.Lock()
+	defer i.localsMutex.Unlock()
+	i.Locals[expr] = local
+}
+
 func (i *Interpreter) interpret(statements []Statement, replMode bool) {
 	for _, stmt := range statements {
 		if stmt != nil {
@@ -52,7 +96,7 @@ func (i *Interpreter) interpret(statements []Statement, replMode bool) {
 			}
 
 			if replMode && res != nil {
-				fmt.Println(res)
+				fmt.Println(stringify(res))
 			}
 		}
 	}
@@ -63,20 +107,32 @@ func (i *Interpreter) VisitVarAssignment(v *VarAssignment) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := i.assign(v, newValue); err != nil {
+		return nil, err
+	}
+	return newValue, nil
+}
 
-	local, found := i.Locals[v]
+func (i *Interpreter) assign(v *VarAssignment, newValue any) error {
+	local, found := i.local(v)
 	if found {
-		i.Environment.AssignAt(local.Distance, *v.Token, newValue)
+		if err := i.Environment.AssignAt(local.Distance, *v.Token, newValue); err != nil {
+			return err
+		}
 	} else {
 		// search in global
+		i.Globals.mutex.Lock()
+		defer i.Globals.mutex.Unlock()
 		for _, val := range i.Globals.Identifiers {
 			if val.Name == v.Token.Lexeme {
+				if val.Constant {
+					return CreateRuntimeError(v.Token, "Cannot assign to constant "+val.Name)
+				}
 				val.Value = newValue
 			}
 		}
 	}
-
-	return newValue, nil
+	return nil
 }
 
 func (i *Interpreter) VisitFunction(f *Function) (any, error) {
@@ -98,6 +154,10 @@ func (i *Interpreter) VisitFunction(f *Function) (any, error) {
 	return val, nil
 }
 
+func (i *Interpreter) VisitNamedArgument(n *NamedArgument) (any, error) {
+	return n.Value.accept(i)
+}
+
 func (i *Interpreter) VisitFunctionDeclaration(f *FunctionDeclaration) (any, error) {
 	name := f.Identifier.Lexeme
 	_, err := i.Environment.GetCurrentBlock(name)
@@ -107,7 +167,7 @@ func (i *Interpreter) VisitFunctionDeclaration(f *FunctionDeclaration) (any, err
 		return nil, CreateRuntimeError(f.Identifier, "Redeclaration of name "+name)
 	}
 
-	i.Environment.Set(name, f)
+	i.Environment.Set(name, &Closure{Declaration: f, Env: i.Environment, scopes: i.Environment.weakScopes()})
 	return nil, nil
 }
 
@@ -138,7 +198,11 @@ func (i *Interpreter) VisitVarDeclaration(v *VarDeclaration) (any, error) {
 		// Variable is redeclared
 		return nil, CreateRuntimeError(v.Identifier, "Redeclaration of name "+name)
 	}
-	i.Environment.Set(name, value)
+	if v.Constant {
+		i.Environment.SetConstant(name, value)
+	} else {
+		i.Environment.Set(name, value)
+	}
 	return value, nil
 }
 
@@ -181,23 +245,88 @@ func (i *Interpreter) VisitIfStatement(ifs *IfStatement) (any, error) {
 }
 
 func (i *Interpreter) VisitWhileStatement(w *WhileStatement) (any, error) {
+	first := w.DoWhile
 	for {
-		val, err := w.Expr.accept(i)
+		if !first {
+			val, err := w.Expr.accept(i)
+			if err != nil {
+				return val, err
+			}
+
+			if !i.isTruthy(val) {
+				break
+			}
+		}
+		first = false
+
+		val, err := w.Stmt.accept(i)
 		if err != nil {
+			if err == BreakStmtErr {
+				return nil, nil
+			}
 			return val, err
 		}
+	}
+	return i.runLoopElse(w.Else)
+}
 
-		if !i.isTruthy(val) {
+// Runs the else block of a loop that ended without break
+func (i *Interpreter) runLoopElse(stmt Statement) (any, error) {
+	if stmt == nil {
+		return nil, nil
+	}
+	return stmt.accept(i)
+}
+
+// Runs the first case having a label equal to the value, or the default case.
+// Cases don't fall through unless they end with `fallthrough`.
+func (i *Interpreter) VisitSwitchStatement(s *SwitchStatement) (any, error) {
+	value, err := i.evaluate(s.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	start := -1
+	for idx, switchCase := range s.Cases {
+		if switchCase.Labels == nil {
+			continue
+		}
+		for _, label := range switchCase.Labels {
+			labelVal, err := i.evaluate(label)
+			if err != nil {
+				return nil, err
+			}
+			if i.isEqual(value, labelVal) {
+				start = idx
+				break
+			}
+		}
+		if start != -1 {
 			break
 		}
+	}
+	if start == -1 {
+		for idx, switchCase := range s.Cases {
+			if switchCase.Labels == nil {
+				start = idx
+			}
+		}
+	}
+	if start == -1 {
+		return nil, nil
+	}
 
-		val, err = w.Stmt.accept(i)
+	for _, switchCase := range s.Cases[start:] {
+		val, err := switchCase.Body.accept(i)
 		if err != nil {
 			if err == BreakStmtErr {
 				break
 			}
 			return val, err
 		}
+		if !switchCase.Fallthrough {
+			break
+		}
 	}
 	return nil, nil
 }
@@ -207,8 +336,7 @@ func (i *Interpreter) VisitPrintStatement(p *PrintStatement) error {
 	if err != nil {
 		return err
 	}
-	// TODO : need to have `.toString()` method to be called
-	fmt.Println(expr)
+	fmt.Println(stringify(expr))
 	return nil
 }
 
@@ -270,77 +398,63 @@ func (i *Interpreter) VisitBinary(b *Binary) (any, error) {
 	}
 
 	switch b.Operator.Type {
-	case MINUS:
+	case MINUS, STAR, SLASH:
 		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) - right.(float64), nil
+		return i.arithmetic(b.Operator, left, right)
 
 	case PLUS:
 		if err := i.checkExprNumber(b.Operator, left, right); err == nil {
-			return left.(float64) + right.(float64), nil
+			return i.arithmetic(b.Operator, left, right)
 		}
 		if err := i.checkExprString(b.Operator, left, right); err == nil {
 			return left.(string) + right.(string), nil
 		}
 		if err := i.checkExprString(b.Operator, left); err == nil {
 			if err := i.checkExprNumber(b.Operator, right); err == nil {
-				return left.(string) + fmt.Sprint(right.(float64)), nil
+				return left.(string) + stringify(right), nil
 			}
 		}
 		if err := i.checkExprNumber(b.Operator, left); err == nil {
 			if err := i.checkExprString(b.Operator, right); err == nil {
-				return fmt.Sprint(left.(float64)) + right.(string), nil
+				return stringify(left) + right.(string), nil
 			}
 		}
 		return nil, CreateRuntimeError(b.Operator, "Addition not supported")
 
-	case SLASH:
-		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
-			return nil, err
-		}
-		// Divide by 0
-		if right == 0.0 {
-			return nil, CreateRuntimeError(b.Operator, "Cannot divide by 0")
-		}
-		return left.(float64) / right.(float64), nil
-
-	case STAR:
-		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
-			return nil, err
-		}
-		return left.(float64) * right.(float64), nil
-
 	case GREATER:
 		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) > right.(float64), nil
+		return i.compare(left, right) > 0, nil
 
 	case GREATER_EQUAL:
 		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
 			return nil, err
 		}
-		r := (left.(float64)) >= (right.(float64))
-		return r, nil
+		return i.compare(left, right) >= 0, nil
 
 	case LESS:
 		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) < right.(float64), nil
+		return i.compare(left, right) < 0, nil
 
 	case LESS_EQUAL:
 		if err := i.checkExprNumber(b.Operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) <= right.(float64), nil
+		return i.compare(left, right) <= 0, nil
+
+	case IN:
+		return i.contains(b.Operator, left, right)
 
 	case BANG_EQUAL:
-		return (left != right), nil
+		return !i.isEqual(left, right), nil
 
 	case EQUAL_EQUAL:
-		return (left == right), nil
+		return i.isEqual(left, right), nil
 	}
 	return nil, errors.New("Unreachable")
 }
@@ -353,17 +467,163 @@ func (i *Interpreter) VisitUnary(u *Unary) (any, error) {
 	}
 	switch u.Operand.Type {
 	case MINUS:
-		val, ok := val.(float64)
-		if !ok {
-			return nil, CreateRuntimeError(u.Operand, "Conversion error")
-		}
-		return -(val), nil
+		return i.negate(u.Operand, val)
 	case BANG:
 		return !i.isTruthy(val), nil
 	}
 	panic("Unreachable")
 }
 
+func (i *Interpreter) VisitInterpolation(in *Interpolation) (any, error) {
+	str := ""
+	for _, part := range in.Parts {
+		val, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		str += stringify(val)
+	}
+	return str, nil
+}
+
+func (i *Interpreter) VisitIndex(in *Index) (any, error) {
+	object, err := i.evaluate(in.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(in.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, ok := index.(*Range); ok {
+		if _, isMap := object.(*Map); !isMap {
+			return i.slice(in.Bracket, object, r)
+		}
+	}
+
+	switch object := object.(type) {
+	case string:
+		// Strings are indexed by characters, not bytes
+		chars := []rune(object)
+		idx, err := i.checkIndex(in.Bracket, index, len(chars))
+		if err != nil {
+			return nil, err
+		}
+		return string(chars[idx]), nil
+	case *List:
+		idx, err := i.checkIndex(in.Bracket, index, len(object.Elements))
+		if err != nil {
+			return nil, err
+		}
+		return object.Elements[idx], nil
+	case *Tuple:
+		idx, err := i.checkIndex(in.Bracket, index, len(object.Elements))
+		if err != nil {
+			return nil, err
+		}
+		return object.Elements[idx], nil
+	case *Map:
+		// Missing keys are nil
+		val, _ := object.Get(index)
+		return val, nil
+	}
+	return nil, CreateRuntimeError(in.Bracket, "Only strings, lists, tuples and maps can be indexed")
+}
+
+func (i *Interpreter) checkIndex(tok *Token, index any, length int) (int, error) {
+	idx, ok := index.(int64)
+	if !ok {
+		return 0, CreateRuntimeError(tok, "Index must be an integer")
+	}
+	if idx < 0 || idx >= int64(length) {
+		return 0, CreateRuntimeError(tok, fmt.Sprintf("Index %d out of range for length %d", idx, length))
+	}
+	return int(idx), nil
+}
+
+func (i *Interpreter) VisitSpread(s *Spread) (any, error) {
+	return nil, CreateRuntimeError(s.Token, "Spread is only allowed in calls and list literals")
+}
+
+// Elements of a spread value
+func (i *Interpreter) spread(s *Spread) ([]any, error) {
+	val, err := i.evaluate(s.Expr)
+	if err != nil {
+		return nil, err
+	}
+	switch val := val.(type) {
+	case *List:
+		return val.Elements, nil
+	case *Tuple:
+		return val.Elements, nil
+	case *Range:
+		if _, ok := val.length(); !ok {
+			return nil, CreateRuntimeError(s.Token, "Cannot spread "+stringify(val)+", it is too long")
+		}
+		elements := []any{}
+		values := &rangeIterator{r: val}
+		for {
+			value, ok, _ := values.next(i)
+			if !ok {
+				return elements, nil
+			}
+			elements = append(elements, value)
+		}
+	}
+	return nil, CreateRuntimeError(s.Token, "Cannot spread "+stringifyNested(val)+", expected a list, a tuple or a range")
+}
+
+func (i *Interpreter) VisitListLiteral(l *ListLiteral) (any, error) {
+	elements := make([]any, 0, len(l.Elements))
+	for _, element := range l.Elements {
+		if spread, ok := element.(*Spread); ok {
+			vals, err := i.spread(spread)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, vals...)
+			continue
+		}
+		val, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, val)
+	}
+	return CreateList(elements), nil
+}
+
+func (i *Interpreter) VisitTupleLiteral(t *TupleLiteral) (any, error) {
+	elements := make([]any, 0, len(t.Elements))
+	for _, element := range t.Elements {
+		val, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, val)
+	}
+	return CreateTuple(elements), nil
+}
+
+func (i *Interpreter) VisitMapLiteral(m *MapLiteral) (any, error) {
+	result := CreateMap()
+	for idx, keyExpr := range m.Keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(m.Values[idx])
+		if err != nil {
+			return nil, err
+		}
+		if !result.Set(key, value) {
+			return nil, CreateRuntimeError(m.Brace, stringifyNested(key)+" can't be used as a map key")
+		}
+	}
+	return result, nil
+}
+
 func (i *Interpreter) VisitLiteral(l *Literal) any {
 	return l.Value
 }
@@ -386,24 +646,82 @@ func (i *Interpreter) evaluate(exp Expression) (any, error) {
 
 func (i *Interpreter) isTruthy(exp any) bool {
 	// TODO: handle nil
-	if exp == nil || exp == 0 {
+	if exp == nil {
 		return false
 	}
-	switch exp.(type) {
+	switch exp := exp.(type) {
 	case bool:
-		return exp.(bool)
+		return exp
+	case int64, *big.Int, *Decimal, float64:
+		return !isZero(exp)
 	}
 	return true
 }
 
+func (i *Interpreter) isEqual(left any, right any) bool {
+	if i.isNumber(left) && i.isNumber(right) {
+		return i.compare(left, right) == 0
+	}
+
+	switch left := left.(type) {
+	case *List:
+		right, ok := right.(*List)
+		if !ok || len(left.Elements) != len(right.Elements) {
+			return false
+		}
+		for idx, element := range left.Elements {
+			if !i.isEqual(element, right.Elements[idx]) {
+				return false
+			}
+		}
+		return true
+	case *Map:
+		right, ok := right.(*Map)
+		if !ok || left.Len() != right.Len() {
+			return false
+		}
+		for _, entry := range left.Entries() {
+			val, found := right.Get(entry.Key)
+			if !found || !i.isEqual(entry.Value, val) {
+				return false
+			}
+		}
+		return true
+	case *Range:
+		right, ok := right.(*Range)
+		return ok && *left == *right
+	case *Tuple:
+		right, ok := right.(*Tuple)
+		if !ok {
+			return false
+		}
+		return i.isEqual(CreateList(left.Elements), CreateList(right.Elements))
+	case *EnumValue:
+		right, ok := right.(*EnumValue)
+		if !ok || left.Variant != right.Variant {
+			return false
+		}
+		for idx, value := range left.Values {
+			if !i.isEqual(value, right.Values[idx]) {
+				return false
+			}
+		}
+		return true
+	}
+	return left == right
+}
+
 func (i *Interpreter) isString(exp any) bool {
 	_, ok := exp.(string)
 	return ok
 }
 
 func (i *Interpreter) isNumber(exp any) bool {
-	_, ok := exp.(float64)
-	return ok
+	switch exp.(type) {
+	case int64, *big.Int, *Decimal, float64:
+		return true
+	}
+	return false
 }
 
 func parseFloat(x any) float64 {
@@ -416,8 +734,7 @@ func parseFloat(x any) float64 {
 
 func (i *Interpreter) checkExprNumber(tok *Token, expressions ...any) error {
 	for _, expr := range expressions {
-		_, ok := expr.(float64)
-		if !ok {
+		if !i.isNumber(expr) {
 			return CreateRuntimeError(tok, "Parsing error")
 		}
 	}
@@ -434,6 +751,28 @@ func (i *Interpreter) checkExprString(tok *Token, expressions ...any) error {
 	return nil
 }
 
+func stringify(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case *big.Int:
+		return v.String()
+	case *Decimal:
+		return v.String()
+	case *List:
+		return v.String()
+	case *Map:
+		return v.String()
+	case *Tuple:
+		return v.String()
+	case Callee:
+		return v.toString()
+	}
+	return fmt.Sprint(value)
+}
+
 func CreateRuntimeError(token *Token, msg string) error {
 	return errors.New(fmt.Sprintf("[line %d] Runtime Error : %s\n", token.Line, msg))
 }
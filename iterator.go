@@ -0,0 +1,171 @@
+package main
+
+// Iterables of a `for-in` loop :
+//   - lists : their elements
+//   - strings : their characters
+//   - maps : their keys, or key and value pairs with `for (k, v in map)`
+//   - maps with an `iter` function : what `iter()` returns is iterated
+//   - maps with a `next` function : `next()` is called until it returns nil
+//   - generators : the values they yield
+//
+// With two loop variables, the values must be lists of two elements, which
+// maps produce on their own.
+type Iterator interface {
+	// Returns false once exhausted
+	next(i *Interpreter) (any, bool, error)
+}
+
+// Iterators holding resources release them when a loop stops early
+type closer interface {
+	close()
+}
+
+type listIterator struct {
+	list  *List
+	index int
+}
+
+func (l *listIterator) next(i *Interpreter) (any, bool, error) {
+	if l.index >= len(l.list.Elements) {
+		return nil, false, nil
+	}
+	l.index += 1
+	return l.list.Elements[l.index-1], true, nil
+}
+
+type stringIterator struct {
+	chars []rune
+	index int
+}
+
+func (s *stringIterator) next(i *Interpreter) (any, bool, error) {
+	if s.index >= len(s.chars) {
+		return nil, false, nil
+	}
+	s.index += 1
+	return string(s.chars[s.index-1]), true, nil
+}
+
+// Iterates over the entries present when the loop started
+type mapIterator struct {
+	entries []*MapEntry
+	index   int
+	pairs   bool
+}
+
+func (m *mapIterator) next(i *Interpreter) (any, bool, error) {
+	if m.index >= len(m.entries) {
+		return nil, false, nil
+	}
+	entry := m.entries[m.index]
+	m.index += 1
+	if m.pairs {
+		return CreateList([]any{entry.Key, entry.Value}), true, nil
+	}
+	return entry.Key, true, nil
+}
+
+// A map with a `next` function
+type userIterator struct {
+	fn    Callee
+	token *Token
+}
+
+func (u *userIterator) next(i *Interpreter) (any, bool, error) {
+	val, err := u.fn.call(i, u.token, &[]Expression{})
+	if err != nil || val == nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+func (i *Interpreter) iterate(token *Token, value any, pairs bool) (Iterator, error) {
+	switch value := value.(type) {
+	case Iterator:
+		return value, nil
+	case *List:
+		return &listIterator{list: value}, nil
+	case *Tuple:
+		return &listIterator{list: CreateList(value.Elements)}, nil
+	case *Range:
+		return &rangeIterator{r: value}, nil
+	case string:
+		return &stringIterator{chars: []rune(value)}, nil
+	case *Enum:
+		return &listIterator{list: CreateList(value.members())}, nil
+	case *Map:
+		if iter, ok := value.Get("iter"); ok {
+			if callee, ok := iter.(Callee); ok {
+				iterable, err := callee.call(i, token, &[]Expression{})
+				if err != nil {
+					return nil, err
+				}
+				if iterable == value {
+					return nil, CreateRuntimeError(token, "iter() must not return its own map")
+				}
+				return i.iterate(token, iterable, pairs)
+			}
+		}
+		if next, ok := value.Get("next"); ok {
+			if callee, ok := next.(Callee); ok {
+				return &userIterator{fn: callee, token: token}, nil
+			}
+		}
+		return &mapIterator{entries: value.Entries(), pairs: pairs}, nil
+	}
+	return nil, CreateRuntimeError(token, "Cannot iterate over "+stringifyNested(value))
+}
+
+func (i *Interpreter) VisitForInStatement(f *ForInStatement) (any, error) {
+	iterable, err := i.evaluate(f.Iterable)
+	if err != nil {
+		return nil, err
+	}
+	pairs := len(f.Names) == 2
+	iterator, err := i.iterate(f.Keyword, iterable, pairs)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := iterator.(closer); ok {
+		defer c.close()
+	}
+
+	var pattern Pattern = &BindingPattern{Name: f.Names[0]}
+	if pairs {
+		pattern = &ListPattern{Elements: []Pattern{&BindingPattern{Name: f.Names[0]}, &BindingPattern{Name: f.Names[1]}}}
+	}
+
+	prevEnv := i.Environment
+	defer func() {
+		i.Environment = prevEnv
+	}()
+
+	for {
+		value, ok, err := iterator.next(i)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		bound := map[string]any{}
+		if err := i.destructure(f.Keyword, pattern, value, bound); err != nil {
+			return nil, err
+		}
+
+		// Fresh scope so that closures capture this iteration's values
+		i.Environment = CreateEnvironment(prevEnv, i)
+		for _, name := range f.Names {
+			i.Environment.Set(name.Lexeme, bound[name.Lexeme])
+		}
+		_, err = f.Stmt.accept(i)
+		i.Environment = prevEnv
+		if err != nil {
+			if err == BreakStmtErr {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+	return i.runLoopElse(f.Else)
+}
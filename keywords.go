@@ -1,22 +1,34 @@
 package main
 
 var keywords = map[string]TokenType{
-	"and":    AND,
-	"class":  CLASS,
-	"else":   ELSE,
-	"false":  FALSE,
-	"fun":    FUN,
-	"for":    FOR,
-	"if":     IF,
-	"nil":    NIL,
-	"or":     OR,
-	"print":  PRINT,
-	"return": RETURN,
-	"super":  SUPER,
-	"this":   THIS,
-	"true":   TRUE,
-	"let":    LET,
-	"while":  WHILE,
-	"break":  BREAK,
-	"eof":    EOF,
+	"and":         AND,
+	"class":       CLASS,
+	"else":        ELSE,
+	"false":       FALSE,
+	"fun":         FUN,
+	"for":         FOR,
+	"if":          IF,
+	"nil":         NIL,
+	"or":          OR,
+	"print":       PRINT,
+	"return":      RETURN,
+	"super":       SUPER,
+	"this":        THIS,
+	"true":        TRUE,
+	"let":         LET,
+	"const":       CONST,
+	"while":       WHILE,
+	"break":       BREAK,
+	"match":       MATCH,
+	"switch":      SWITCH,
+	"case":        CASE,
+	"default":     DEFAULT,
+	"fallthrough": FALLTHROUGH,
+	"in":          IN,
+	"yield":       YIELD,
+	"spawn":       SPAWN,
+	"select":      SELECT,
+	"enum":        ENUM,
+	"do":          DO,
+	"eof":         EOF,
 }
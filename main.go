@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -9,20 +10,26 @@ import (
 
 type Lox struct {
 	HadError bool
+	// Warnings don't stop the script
+	HadWarning bool
 	*Interpreter
+	// Run the type checker before interpreting
+	TypeCheck bool
 }
 
 func main() {
-	args := os.Args
-	if len(args) > 2 {
-		fmt.Println("Usage : jlox [help]")
+	check := flag.Bool("check", false, "check type annotations before running")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) > 1 {
+		fmt.Println("Usage : jlox [--check] [script]")
 		os.Exit(1)
 	}
 
-	lox := Lox{}
+	lox := Lox{TypeCheck: *check}
 
-	if len(args) == 2 {
-		byt, err := os.ReadFile(args[1])
+	if len(args) == 1 {
+		byt, err := os.ReadFile(args[0])
 		if err != nil {
 			panic(err)
 		}
@@ -53,14 +60,18 @@ func (lox *Lox) showPrompt() {
 
 func (lox *Lox) error(token Token, msg string) {
 	if token.Type == EOF {
-		lox.printError(token.Line, "at "+"end", msg)
+		lox.printError(token.Line, token.Column, "at "+"end", msg)
 	} else {
-		lox.printError(token.Line, "at "+token.Lexeme, msg)
+		lox.printError(token.Line, token.Column, "at "+token.Lexeme, msg)
 	}
 }
 
-func (lox *Lox) printError(line int, where string, msg string) {
-	fmt.Printf("[line %d] Error %s: %s\n", line, where, msg)
+func (lox *Lox) printError(line int, column int, where string, msg string) {
+	if column > 0 {
+		fmt.Printf("[line %d:%d] Error %s: %s\n", line, column, where, msg)
+	} else {
+		fmt.Printf("[line %d] Error %s: %s\n", line, where, msg)
+	}
 	lox.HadError = true
 }
 
@@ -90,6 +101,14 @@ func (lox *Lox) run(source string, replMode bool) {
 		os.Exit(69)
 	}
 
+	if lox.TypeCheck {
+		CreateTypeChecker(lox).check(statements)
+		if lox.HadError {
+			os.Exit(69)
+		}
+	}
+	CreateTypeInference(lox).check(statements)
+
 	interpreter.interpret(statements, replMode)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -105,5 +124,6 @@ func (l *Lox) Error(token *Token, msg string) {
 
 
 func (l *Lox) Warn(token *Token, msg string) {
+	l.HadWarning = true
 	fmt.Printf("[line %d] Warning : %s\n", token.Line, msg)
 }
@@ -1,22 +1,333 @@
 package main
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 type Clock struct{}
 
-func (c *Clock) call(*Interpreter, *[]Expression) (any, error) {
+func (c *Clock) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	if _, err := i.evaluateArgs(c, token, args); err != nil {
+		return nil, err
+	}
 	return time.Now().UnixMilli(), nil
 }
 
-func (c *Clock) arity() int {
-	return 0
+func (c *Clock) arity() (int, int) {
+	return 0, 0
 }
 
 func (c *Clock) toString() string {
 	return "<native fn>"
 }
 
+// int(x) : truncates floats toward zero and parses strings
+type IntConversion struct{}
+
+func (c *IntConversion) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(c, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := argsVal[0].(type) {
+	case int64:
+		return v, nil
+	case *big.Int, *Decimal, float64:
+		truncated, ok := truncateToBigInt(v)
+		if !ok || !truncated.IsInt64() {
+			return nil, CreateRuntimeError(token, fmt.Sprintf("Cannot convert %s to int", stringify(v)))
+		}
+		return truncated.Int64(), nil
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, CreateRuntimeError(token, "Cannot convert \""+v+"\" to int")
+		}
+		return parsed, nil
+	}
+	return nil, CreateRuntimeError(token, "Cannot convert "+stringify(argsVal[0])+" to int")
+}
+
+func (c *IntConversion) arity() (int, int) {
+	return 1, 1
+}
+
+func (c *IntConversion) toString() string {
+	return "<native fn>"
+}
+
+// float(x) : widens integers and parses strings
+type FloatConversion struct{}
+
+func (c *FloatConversion) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(c, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := argsVal[0].(type) {
+	case int64, *big.Int, *Decimal, float64:
+		return toFloat(v), nil
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, CreateRuntimeError(token, "Cannot convert \""+v+"\" to float")
+		}
+		return parsed, nil
+	}
+	return nil, CreateRuntimeError(token, "Cannot convert "+stringify(argsVal[0])+" to float")
+}
+
+func (c *FloatConversion) arity() (int, int) {
+	return 1, 1
+}
+
+func (c *FloatConversion) toString() string {
+	return "<native fn>"
+}
+
+// bigint(x) : truncates floats and decimals toward zero and parses strings
+type BigIntConversion struct{}
+
+func (c *BigIntConversion) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(c, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := argsVal[0].(type) {
+	case int64, *big.Int, *Decimal, float64:
+		truncated, ok := truncateToBigInt(v)
+		if !ok {
+			return nil, CreateRuntimeError(token, "Cannot convert "+stringify(v)+" to bigint")
+		}
+		return truncated, nil
+	case string:
+		parsed, ok := new(big.Int).SetString(strings.TrimSpace(v), 10)
+		if !ok {
+			return nil, CreateRuntimeError(token, "Cannot convert \""+v+"\" to bigint")
+		}
+		return parsed, nil
+	}
+	return nil, CreateRuntimeError(token, "Cannot convert "+stringify(argsVal[0])+" to bigint")
+}
+
+func (c *BigIntConversion) arity() (int, int) {
+	return 1, 1
+}
+
+func (c *BigIntConversion) toString() string {
+	return "<native fn>"
+}
+
+// decimal(x) : floats are converted from their shortest representation,
+// so decimal(0.1) is exactly 0.1
+type DecimalConversion struct{}
+
+func (c *DecimalConversion) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(c, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := argsVal[0].(type) {
+	case int64, *big.Int, *Decimal:
+		return toDecimal(v), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, CreateRuntimeError(token, "Cannot convert "+stringify(v)+" to decimal")
+		}
+		parsed, _ := parseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+		return parsed, nil
+	case string:
+		parsed, ok := parseDecimal(strings.TrimSpace(v))
+		if !ok {
+			return nil, CreateRuntimeError(token, "Cannot convert \""+v+"\" to decimal")
+		}
+		return parsed, nil
+	}
+	return nil, CreateRuntimeError(token, "Cannot convert "+stringify(argsVal[0])+" to decimal")
+}
+
+func (c *DecimalConversion) arity() (int, int) {
+	return 1, 1
+}
+
+func (c *DecimalConversion) toString() string {
+	return "<native fn>"
+}
+
+// len(x) : number of characters of a string, elements of a list or
+// entries of a map
+type Length struct{}
+
+func (l *Length) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(l, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := argsVal[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	case *List:
+		return int64(len(v.Elements)), nil
+	case *Tuple:
+		return int64(len(v.Elements)), nil
+	case *Range:
+		return v.count(), nil
+	case *Map:
+		return int64(v.Len()), nil
+	}
+	return nil, CreateRuntimeError(token, "Cannot get the length of "+stringify(argsVal[0]))
+}
+
+func (l *Length) arity() (int, int) {
+	return 1, 1
+}
+
+func (l *Length) toString() string {
+	return "<native fn>"
+}
+
+// Returns false when the value is not finite
+func truncateToBigInt(value any) (*big.Int, bool) {
+	switch v := value.(type) {
+	case int64, *big.Int:
+		return toBigInt(v), true
+	case *Decimal:
+		return new(big.Int).Quo(v.Unscaled, pow10(v.Scale)), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		truncated, _ := big.NewFloat(v).Int(nil)
+		return truncated, true
+	}
+	panic("Unreachable")
+}
+
+// resume(generator) : runs the generator up to its next yield and returns the
+// value, or nil once the generator is done
+type Resume struct{}
+
+func (n *Resume) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(n, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	generator, ok := argsVal[0].(*Generator)
+	if !ok {
+		return nil, CreateRuntimeError(token, "Expected a generator but got "+stringify(argsVal[0]))
+	}
+	val, _, err := generator.next(i)
+	return val, err
+}
+
+func (n *Resume) arity() (int, int) {
+	return 1, 1
+}
+
+func (n *Resume) toString() string {
+	return "<native fn>"
+}
+
+// channel() or channel(capacity)
+type ChannelConstructor struct{}
+
+func (c *ChannelConstructor) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(c, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(argsVal) == 0 {
+		return CreateChannel(0), nil
+	}
+	capacity, ok := argsVal[0].(int64)
+	if !ok || capacity < 0 || capacity > math.MaxInt32 {
+		return nil, CreateRuntimeError(token, "Channel capacity must be a non-negative int but got "+stringify(argsVal[0]))
+	}
+	return CreateChannel(int(capacity)), nil
+}
+
+func (c *ChannelConstructor) arity() (int, int) {
+	return 0, 1
+}
+
+func (c *ChannelConstructor) toString() string {
+	return "<native fn>"
+}
+
+// close(channel)
+type Close struct{}
+
+func (c *Close) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(c, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, ok := argsVal[0].(*Channel)
+	if !ok {
+		return nil, CreateRuntimeError(token, "Expected a channel but got "+stringify(argsVal[0]))
+	}
+	if !channel.close() {
+		return nil, CreateRuntimeError(token, "Channel is already closed")
+	}
+	return nil, nil
+}
+
+func (c *Close) arity() (int, int) {
+	return 1, 1
+}
+
+func (c *Close) toString() string {
+	return "<native fn>"
+}
+
+// wait(task) : the value returned by the spawned call
+type Wait struct{}
+
+func (w *Wait) call(i *Interpreter, token *Token, args *[]Expression) (any, error) {
+	argsVal, err := i.evaluateArgs(w, token, args)
+	if err != nil {
+		return nil, err
+	}
+
+	task, ok := argsVal[0].(*Task)
+	if !ok {
+		return nil, CreateRuntimeError(token, "Expected a task but got "+stringify(argsVal[0]))
+	}
+	return task.wait()
+}
+
+func (w *Wait) arity() (int, int) {
+	return 1, 1
+}
+
+func (w *Wait) toString() string {
+	return "<native fn>"
+}
+
 func SetupInterpreter(i *Interpreter) {
 	i.Environment.Set("clock", &Clock{})
+	i.Environment.Set("int", &IntConversion{})
+	i.Environment.Set("float", &FloatConversion{})
+	i.Environment.Set("bigint", &BigIntConversion{})
+	i.Environment.Set("decimal", &DecimalConversion{})
+	i.Environment.Set("len", &Length{})
+	i.Environment.Set("resume", &Resume{})
+	i.Environment.Set("channel", &ChannelConstructor{})
+	i.Environment.Set("close", &Close{})
+	i.Environment.Set("wait", &Wait{})
 	i.Environment.Set("bar", &FunctionDeclaration{})
 }
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Numbers come in four flavours :
+//   - integers (int64) : integer literals
+//   - bigints (*big.Int) : literals with a `n` suffix (`10n`), integer
+//     literals that do not fit in int64 and integer operations that overflow
+//   - decimals (*Decimal) : literals with a `d` suffix (`0.10d`), exact
+//     base-10 fractions meant for money
+//   - floats (float64) : literals with a fractional part
+//
+// When an operation mixes flavours, the operand of the lower flavour is
+// promoted following int -> bigint -> decimal and int, bigint -> float.
+// Mixing decimals and floats in arithmetic is a runtime error as the result
+// would silently lose its exactness, convert explicitly with `decimal(x)` or
+// `float(x)` instead. Comparisons accept any mix.
+//
+// Integer arithmetic never wraps around : an operation whose result does not
+// fit in int64 is promoted to a bigint. Integer division truncates toward
+// zero, like Go. Bigints stay bigints even when the result is small enough
+// for int64, use `int(x)` to convert back.
+
+type Decimal struct {
+	// Value is Unscaled * 10^-Scale
+	Unscaled *big.Int
+	Scale    int
+}
+
+// Number of fractional digits kept when a decimal division doesn't terminate.
+// The last digit is rounded half to even.
+const DECIMAL_DIVISION_SCALE = 28
+
+// Keeps `1e999999999d` from allocating a number with a billion digits
+const MAX_DECIMAL_EXPONENT = 9999
+
+// Numeric flavours, ordered by promotion
+const (
+	INT_NUMBER = iota
+	BIGINT_NUMBER
+	DECIMAL_NUMBER
+	FLOAT_NUMBER
+)
+
+func numberKind(value any) int {
+	switch value.(type) {
+	case int64:
+		return INT_NUMBER
+	case *big.Int:
+		return BIGINT_NUMBER
+	case *Decimal:
+		return DECIMAL_NUMBER
+	case float64:
+		return FLOAT_NUMBER
+	}
+	panic("Unreachable")
+}
+
+func isInteger(value any) bool {
+	_, ok := value.(int64)
+	return ok
+}
+
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case *Decimal:
+		f, _ := v.rat().Float64()
+		return f
+	case float64:
+		return v
+	}
+	panic("Unreachable")
+}
+
+func toBigInt(value any) *big.Int {
+	switch v := value.(type) {
+	case int64:
+		return big.NewInt(v)
+	case *big.Int:
+		return v
+	}
+	panic("Unreachable")
+}
+
+func toDecimal(value any) *Decimal {
+	switch v := value.(type) {
+	case int64:
+		return &Decimal{Unscaled: big.NewInt(v), Scale: 0}
+	case *big.Int:
+		return &Decimal{Unscaled: v, Scale: 0}
+	case *Decimal:
+		return v
+	}
+	panic("Unreachable")
+}
+
+// Returns nil when the value is not finite
+func toRat(value any) *big.Rat {
+	switch v := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v)
+	case *big.Int:
+		return new(big.Rat).SetInt(v)
+	case *Decimal:
+		return v.rat()
+	case float64:
+		return new(big.Rat).SetFloat64(v)
+	}
+	panic("Unreachable")
+}
+
+func isZero(value any) bool {
+	switch v := value.(type) {
+	case int64:
+		return v == 0
+	case *big.Int:
+		return v.Sign() == 0
+	case *Decimal:
+		return v.Unscaled.Sign() == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+// Both operands must already be checked with `checkExprNumber`
+func (i *Interpreter) arithmetic(operator *Token, left any, right any) (any, error) {
+	kind := max(numberKind(left), numberKind(right))
+	if kind == FLOAT_NUMBER && (numberKind(left) == DECIMAL_NUMBER || numberKind(right) == DECIMAL_NUMBER) {
+		return nil, CreateRuntimeError(operator, "Cannot mix decimal and float, convert one of them first")
+	}
+	if operator.Type == SLASH && isZero(right) {
+		return nil, CreateRuntimeError(operator, "Cannot divide by 0")
+	}
+
+	switch kind {
+	case INT_NUMBER:
+		return i.integerArithmetic(operator, left.(int64), right.(int64))
+	case BIGINT_NUMBER:
+		return i.bigIntArithmetic(operator, toBigInt(left), toBigInt(right))
+	case DECIMAL_NUMBER:
+		return i.decimalArithmetic(operator, toDecimal(left), toDecimal(right))
+	}
+
+	a, b := toFloat(left), toFloat(right)
+	switch operator.Type {
+	case PLUS:
+		return a + b, nil
+	case MINUS:
+		return a - b, nil
+	case STAR:
+		return a * b, nil
+	case SLASH:
+		return a / b, nil
+	}
+	panic("Unreachable")
+}
+
+func (i *Interpreter) integerArithmetic(operator *Token, a int64, b int64) (any, error) {
+	switch operator.Type {
+	case PLUS:
+		if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+			break
+		}
+		return a + b, nil
+	case MINUS:
+		if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+			break
+		}
+		return a - b, nil
+	case STAR:
+		res := a * b
+		if a != 0 && (res/a != b || (a == -1 && b == math.MinInt64)) {
+			break
+		}
+		return res, nil
+	case SLASH:
+		if a == math.MinInt64 && b == -1 {
+			break
+		}
+		return a / b, nil
+	default:
+		panic("Unreachable")
+	}
+	// Overflow
+	return i.bigIntArithmetic(operator, big.NewInt(a), big.NewInt(b))
+}
+
+func (i *Interpreter) bigIntArithmetic(operator *Token, a *big.Int, b *big.Int) (any, error) {
+	res := new(big.Int)
+	switch operator.Type {
+	case PLUS:
+		return res.Add(a, b), nil
+	case MINUS:
+		return res.Sub(a, b), nil
+	case STAR:
+		return res.Mul(a, b), nil
+	case SLASH:
+		return res.Quo(a, b), nil
+	}
+	panic("Unreachable")
+}
+
+func (i *Interpreter) decimalArithmetic(operator *Token, a *Decimal, b *Decimal) (any, error) {
+	switch operator.Type {
+	case PLUS:
+		x, y, scale := alignDecimals(a, b)
+		return &Decimal{Unscaled: new(big.Int).Add(x, y), Scale: scale}, nil
+	case MINUS:
+		x, y, scale := alignDecimals(a, b)
+		return &Decimal{Unscaled: new(big.Int).Sub(x, y), Scale: scale}, nil
+	case STAR:
+		return &Decimal{Unscaled: new(big.Int).Mul(a.Unscaled, b.Unscaled), Scale: a.Scale + b.Scale}, nil
+	case SLASH:
+		return decimalFromRat(new(big.Rat).Quo(a.rat(), b.rat())), nil
+	}
+	panic("Unreachable")
+}
+
+// Returns -1, 0 or 1. Both operands must be numbers
+func (i *Interpreter) compare(left any, right any) int {
+	if isInteger(left) && isInteger(right) {
+		a, b := left.(int64), right.(int64)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	}
+
+	if numberKind(left) != FLOAT_NUMBER || numberKind(right) != FLOAT_NUMBER {
+		a, b := toRat(left), toRat(right)
+		if a != nil && b != nil {
+			return a.Cmp(b)
+		}
+	}
+
+	a, b := toFloat(left), toFloat(right)
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func (i *Interpreter) negate(operator *Token, value any) (any, error) {
+	switch v := value.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(v)), nil
+		}
+		return -v, nil
+	case *big.Int:
+		return new(big.Int).Neg(v), nil
+	case *Decimal:
+		return &Decimal{Unscaled: new(big.Int).Neg(v.Unscaled), Scale: v.Scale}, nil
+	case float64:
+		return -v, nil
+	}
+	return nil, CreateRuntimeError(operator, "Conversion error")
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func alignDecimals(a *Decimal, b *Decimal) (*big.Int, *big.Int, int) {
+	scale := max(a.Scale, b.Scale)
+	x := new(big.Int).Mul(a.Unscaled, pow10(scale-a.Scale))
+	y := new(big.Int).Mul(b.Unscaled, pow10(scale-b.Scale))
+	return x, y, scale
+}
+
+func (d *Decimal) rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.Unscaled, pow10(d.Scale))
+}
+
+// Exact when the fraction terminates in base 10, rounded to
+// DECIMAL_DIVISION_SCALE digits otherwise
+func decimalFromRat(r *big.Rat) *Decimal {
+	rest := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	for rest.Bit(0) == 0 {
+		rest.Rsh(rest, 1)
+		twos += 1
+	}
+	five, mod := big.NewInt(5), new(big.Int)
+	for {
+		quo, _ := new(big.Int).QuoRem(rest, five, mod)
+		if mod.Sign() != 0 {
+			break
+		}
+		rest = quo
+		fives += 1
+	}
+
+	scale := max(twos, fives)
+	if rest.Cmp(big.NewInt(1)) != 0 {
+		scale = DECIMAL_DIVISION_SCALE
+	}
+
+	num := new(big.Int).Mul(r.Num(), pow10(scale))
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+
+	// Round half to even
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	cmp := twiceRem.Cmp(r.Denom())
+	if cmp > 0 || (cmp == 0 && quo.Bit(0) == 1) {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return &Decimal{Unscaled: quo, Scale: scale}
+}
+
+// Accepts `[-]digits[.digits][e[+-]digits]`
+func parseDecimal(text string) (*Decimal, bool) {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(text), "e")
+	digits, fraction, _ := strings.Cut(mantissa, ".")
+	unscaled, ok := new(big.Int).SetString(digits+fraction, 10)
+	if !ok || strings.ContainsAny(digits+fraction, "+_") || strings.HasPrefix(fraction, "-") {
+		return nil, false
+	}
+
+	scale := len(fraction)
+	if hasExponent {
+		exp, err := strconv.Atoi(exponent)
+		if err != nil || exp > MAX_DECIMAL_EXPONENT || exp < -MAX_DECIMAL_EXPONENT {
+			return nil, false
+		}
+		scale -= exp
+	}
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return &Decimal{Unscaled: unscaled, Scale: scale}, true
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.Unscaled).String()
+	if d.Scale > 0 {
+		if len(digits) <= d.Scale {
+			digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.Scale] + "." + digits[len(digits)-d.Scale:]
+	}
+	if d.Unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
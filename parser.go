@@ -9,6 +9,9 @@ type Parser struct {
 	*Lox
 	Current int
 	Tokens  []Token
+	// Set while a loop is the statement of an if, the else after the loop
+	// belonging to the if
+	loopInIf bool
 }
 
 func CreateParser(tokens []Token, lox *Lox) *Parser {
@@ -38,12 +41,18 @@ func (p *Parser) parseDeclaration() (Statement, error) {
 	if p.match(LET) {
 		return p.parseVarDeclaration()
 	}
+	if p.match(CONST) {
+		return p.parseConstDeclaration()
+	}
 	if p.match(FUN) {
 		if p.match(LEFT_PAREN) {
 			return p.parseFunctionExpression()
 		}
 		return p.parseFunctionDeclaration()
 	}
+	if p.match(ENUM) {
+		return p.parseEnumDeclaration()
+	}
 	return p.parseStatement()
 }
 
@@ -58,6 +67,9 @@ func (p *Parser) parseStatement() (Statement, error) {
 	if p.match(WHILE) {
 		return p.parseWhile()
 	}
+	if p.match(DO) {
+		return p.parseDoWhile()
+	}
 	if p.match(LEFT_BRACE) {
 		statements, err := p.block()
 		if err != nil {
@@ -77,6 +89,15 @@ func (p *Parser) parseStatement() (Statement, error) {
 	if p.match(RETURN) {
 		return p.parseReturn()
 	}
+	if p.match(YIELD) {
+		return p.parseYield()
+	}
+	if p.match(SWITCH) {
+		return p.parseSwitch()
+	}
+	if p.match(SELECT) {
+		return p.parseSelect()
+	}
 
 	parsed, err := p.parseExpressionStatement()
 	if err != nil {
@@ -91,7 +112,9 @@ func (p *Parser) parseIf() (Statement, error) {
 		return nil, err
 	}
 
+	p.loopInIf = p.check(WHILE) || p.check(FOR)
 	ifStmt, err := p.parseStatement()
+	p.loopInIf = false
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +132,7 @@ func (p *Parser) parseIf() (Statement, error) {
 
 // while (expr) stmt
 func (p *Parser) parseWhile() (Statement, error) {
+	takesElse := p.takesLoopElse()
 	expr, err := p.parseExpression()
 	if err != nil {
 		return nil, err
@@ -121,14 +145,84 @@ func (p *Parser) parseWhile() (Statement, error) {
 	}
 
 	blockStmt := CreateBlock(stmts)
-	return CreateWhileStatement(expr, blockStmt), nil
+	while := CreateWhileStatement(expr, blockStmt)
+	while.Else, err = p.parseLoopElse(takesElse)
+	if err != nil {
+		return nil, err
+	}
+	return while, nil
+}
+
+// do { ... } while (cond);
+func (p *Parser) parseDoWhile() (Statement, error) {
+	_, err := p.consume(LEFT_BRACE, "Expected opening brace '{' after do")
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(WHILE, "Expected 'while' after do block")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LEFT_PAREN, "Expected opening parentheses '(' after while")
+	if err != nil {
+		return nil, err
+	}
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')'")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(SEMICOLON, "Expected semicolon ';' after do-while condition")
+	if err != nil {
+		return nil, err
+	}
+
+	while := CreateWhileStatement(expr, CreateBlock(stmts))
+	while.DoWhile = true
+	return while, nil
+}
+
+// Whether the loop starting can have an else, false when the loop is the
+// statement of an if
+func (p *Parser) takesLoopElse() bool {
+	takesElse := !p.loopInIf
+	p.loopInIf = false
+	return takesElse
+}
+
+// Optional `else { ... }` after a loop, nil when absent
+func (p *Parser) parseLoopElse(takesElse bool) (Statement, error) {
+	if !takesElse || !p.match(ELSE) {
+		return nil, nil
+	}
+	_, err := p.consume(LEFT_BRACE, "Expected opening brace '{' after else")
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return CreateBlock(stmts), nil
 }
 
 func (p *Parser) parseFor() (Statement, error) {
+	keyword := p.previous()
+	takesElse := p.takesLoopElse()
 	_, err := p.consume(LEFT_PAREN, "Expected left parentheses ')' after for")
 	if err != nil {
 		return nil, err
 	}
+	if p.check(IDENTIFIER) && (p.checkNext(IN) || p.checkNext(COMMA)) {
+		return p.parseForIn(keyword, takesElse)
+	}
 
 	var declr Statement
 	if p.match(SEMICOLON) {
@@ -188,7 +282,12 @@ func (p *Parser) parseFor() (Statement, error) {
 	}
 	expr = condition
 
-	res := []Statement{CreateWhileStatement(expr, stmt)}
+	while := CreateWhileStatement(expr, stmt)
+	while.Else, err = p.parseLoopElse(takesElse)
+	if err != nil {
+		return nil, err
+	}
+	res := []Statement{while}
 	if declr != nil {
 		res = append([]Statement{declr}, res...)
 	}
@@ -196,209 +295,729 @@ func (p *Parser) parseFor() (Statement, error) {
 	return CreateBlock(res), nil
 }
 
-func (p *Parser) block() ([]Statement, error) {
-	statements := make([]Statement, 0)
-	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
-		stmt, err := p.parseDeclaration()
+// for (x in iterable) { }, for (k, v in map) { }
+func (p *Parser) parseForIn(keyword *Token, takesElse bool) (Statement, error) {
+	names := []*Token{p.advance()}
+	if p.match(COMMA) {
+		name, err := p.consume(IDENTIFIER, "Expected identifier after ','")
 		if err != nil {
 			return nil, err
 		}
-		statements = append(statements, stmt)
+		if name.Lexeme == names[0].Lexeme {
+			return nil, p.CreateCompileError(name, "Duplicate loop variable "+name.Lexeme)
+		}
+		names = append(names, name)
 	}
-	_, err := p.consume(RIGHT_BRACE, "Expected closing bracket '}'")
+	_, err := p.consume(IN, "Expected 'in' after loop variables")
 	if err != nil {
 		return nil, err
 	}
-	return statements, nil
-}
 
-func (p *Parser) parseVarDeclaration() (Statement, error) {
-	identifier, err := p.consume(IDENTIFIER, "Expect variable name")
+	iterable, err := p.parseExpression()
 	if err != nil {
 		return nil, err
 	}
-	var initValue Expression = CreateLiteral(Nil{})
-	if p.match(EQUAL) {
-		expr, err := p.parseExpression()
-		if err != nil {
-			return nil, err
-		}
-		initValue = expr
-	}
-
-	if _, err := p.consume(SEMICOLON, "Missing semicolon ; at the end of statement"); err != nil {
+	_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')'")
+	if err != nil {
 		return nil, err
 	}
-	return CreateVarDeclaration(initValue, identifier), nil
-}
-
-func (p *Parser) parseFunctionDeclaration() (Statement, error) {
-	identifier, err := p.consume(IDENTIFIER, "Expected identifier after function declaration")
+	_, err = p.consume(LEFT_BRACE, "Expected opening brace '{'")
 	if err != nil {
 		return nil, err
 	}
-
-	params, stmts, err := p.parseFunction()
+	stmts, err := p.block()
 	if err != nil {
 		return nil, err
 	}
 
-	return CreateFunctionDeclaration(identifier, params, stmts), nil
-}
-
-func (p *Parser) parseFunctionExpression() (Statement, error) {
-	_, _, err := p.parseFunction()
+	forIn := CreateForInStatement(keyword, names, iterable, CreateBlock(stmts))
+	forIn.Else, err = p.parseLoopElse(takesElse)
 	if err != nil {
 		return nil, err
 	}
-	panic("TODO")
+	return forIn, nil
 }
 
-func (p *Parser) parseFunction() ([]*Token, []Statement, error) {
-	_, err := p.consume(LEFT_PAREN, "Expected opening parentheses '(' in function declaration")
+// switch (value) { case 1: ... case 2, 3: ... fallthrough; default: ... }
+func (p *Parser) parseSwitch() (Statement, error) {
+	keyword := p.previous()
+	_, err := p.consume(LEFT_PAREN, "Expected opening parentheses '(' after switch")
 	if err != nil {
-		return nil, nil, err
-	}
-
-	params := []*Token{}
-	if p.match(IDENTIFIER) {
-		params = append(params, p.previous())
-
-		for p.match(COMMA) {
-			param, err := p.consume(IDENTIFIER, "Expected identifier after ','")
-			if err != nil {
-				return nil, nil, err
-			}
-			params = append(params, param)
-		}
+		return nil, err
 	}
-
-	if len(params) >= 255 {
-		p.Lox.Error(p.peek(), "Can't have more than 255 args")
-		return nil, nil, nil
+	value, err := p.parseExpression()
+	if err != nil {
+		return nil, err
 	}
-
 	_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')'")
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	_, err = p.consume(LEFT_BRACE, "Expected opening braces '{' in function declaration")
+	_, err = p.consume(LEFT_BRACE, "Expected opening brace '{' after switch value")
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	stmt, err := p.block()
-	if err != nil {
-		return nil, nil, err
-	}
+	cases := []*SwitchCase{}
+	hasDefault := false
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		switchCase := &SwitchCase{}
+		if p.match(DEFAULT) {
+			if hasDefault {
+				return nil, p.CreateCompileError(p.previous(), "Multiple default cases in switch")
+			}
+			hasDefault = true
+			switchCase.Token = p.previous()
+		} else {
+			switchCase.Token, err = p.consume(CASE, "Expected 'case' or 'default' in switch")
+			if err != nil {
+				return nil, err
+			}
+			for {
+				label, err := p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+				switchCase.Labels = append(switchCase.Labels, label)
+				if !p.match(COMMA) {
+					break
+				}
+			}
+		}
+		_, err = p.consume(COLON, "Expected ':' after case")
+		if err != nil {
+			return nil, err
+		}
 
-	return params, stmt, nil
-}
+		stmts := []Statement{}
+		for !p.check(CASE) && !p.check(DEFAULT) && !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+			if p.match(FALLTHROUGH) {
+				keyword := p.previous()
+				_, err = p.consume(SEMICOLON, "Expected semicolon")
+				if err != nil {
+					return nil, err
+				}
+				if p.check(RIGHT_BRACE) {
+					return nil, p.CreateCompileError(keyword, "Cannot fallthrough the final case in switch")
+				}
+				if !p.check(CASE) && !p.check(DEFAULT) {
+					return nil, p.CreateCompileError(keyword, "fallthrough must be the last statement of a case")
+				}
+				switchCase.Fallthrough = true
+				break
+			}
 
-func (p *Parser) parsePrint() (Statement, error) {
-	expr, err := p.parseExpression()
-	if err != nil {
-		return nil, err
+			stmt, err := p.parseDeclaration()
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+		switchCase.Body = CreateBlock(stmts)
+		cases = append(cases, switchCase)
 	}
 
-	_, err = p.consume(SEMICOLON, "Expected ; after expression")
+	_, err = p.consume(RIGHT_BRACE, "Expected closing brace '}' after switch cases")
 	if err != nil {
 		return nil, err
 	}
-	return CreatePrintStatement(expr), nil
+	return CreateSwitchStatement(keyword, value, cases), nil
 }
-func (p *Parser) parseExpressionStatement() (Statement, error) {
-	expr, err := p.parseExpression()
+
+// channel <- value;
+func (p *Parser) parseSend(channel Expression) (Statement, error) {
+	arrow := p.previous()
+	value, err := p.parseExpression()
 	if err != nil {
 		return nil, err
 	}
-
 	_, err = p.consume(SEMICOLON, "Expected ; after expression: "+p.peek().Lexeme)
 	if err != nil {
 		return nil, err
 	}
-	return CreateExpressionStatement(expr), nil
+	return CreateSendStatement(channel, arrow, value), nil
 }
 
-func (p *Parser) parseBreak() (Statement, error) {
-	breakStmt := CreateBreakStatement()
-	_, err := p.consume(SEMICOLON, "Expected semicolon")
+// select { case let v = <-a: ... case <-b: ... case c <- 1: ... default: ... }
+func (p *Parser) parseSelect() (Statement, error) {
+	keyword := p.previous()
+	_, err := p.consume(LEFT_BRACE, "Expected opening brace '{' after select")
 	if err != nil {
 		return nil, err
 	}
-	return breakStmt, nil
-}
 
-func (p *Parser) parseReturn() (Statement, error) {
-	var expr Expression = nil
-	var err error = nil
+	cases := []*SelectCase{}
+	hasDefault := false
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		selectCase := &SelectCase{}
+		if p.match(DEFAULT) {
+			if hasDefault {
+				return nil, p.CreateCompileError(p.previous(), "Multiple default cases in select")
+			}
+			hasDefault = true
+			selectCase.Token = p.previous()
+		} else {
+			selectCase.Token, err = p.consume(CASE, "Expected 'case' or 'default' in select")
+			if err != nil {
+				return nil, err
+			}
+			if p.match(LET) {
+				selectCase.Name, err = p.consume(IDENTIFIER, "Expected identifier after let")
+				if err != nil {
+					return nil, err
+				}
+				_, err = p.consume(EQUAL, "Expected '=' after identifier")
+				if err != nil {
+					return nil, err
+				}
+				if !p.check(LEFT_ARROW) {
+					return nil, p.CreateCompileError(p.peek(), "Expected a receive '<-' in select case")
+				}
+			}
 
-	if p.peek().Type != SEMICOLON {
-		expr, err = p.parseExpression()
+			if p.match(LEFT_ARROW) {
+				selectCase.Channel, err = p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				selectCase.Channel, err = p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+				_, err = p.consume(LEFT_ARROW, "Expected a send or receive '<-' in select case")
+				if err != nil {
+					return nil, err
+				}
+				selectCase.Value, err = p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		_, err = p.consume(COLON, "Expected ':' after case")
 		if err != nil {
 			return nil, err
 		}
+
+		stmts := []Statement{}
+		for !p.check(CASE) && !p.check(DEFAULT) && !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+			stmt, err := p.parseDeclaration()
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, stmt)
+		}
+		selectCase.Body = CreateBlock(stmts)
+		cases = append(cases, selectCase)
 	}
 
-	token, err := p.consume(SEMICOLON, "Expected ; after expression: "+p.peek().Lexeme)
+	_, err = p.consume(RIGHT_BRACE, "Expected closing brace '}' after select cases")
 	if err != nil {
 		return nil, err
 	}
-	return CreateReturnStatement(token, expr), nil
+	return CreateSelectStatement(keyword, cases), nil
 }
 
-func (p *Parser) parseExpression() (Expression, error) {
-	return p.parseAssignment()
+func (p *Parser) block() ([]Statement, error) {
+	statements := make([]Statement, 0)
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		stmt, err := p.parseDeclaration()
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	_, err := p.consume(RIGHT_BRACE, "Expected closing bracket '}'")
+	if err != nil {
+		return nil, err
+	}
+	return statements, nil
 }
 
-// a = 4;
-func (p *Parser) parseAssignment() (Expression, error) {
-	expr, err := p.parseComma()
+func (p *Parser) parseVarDeclaration() (Statement, error) {
+	if p.check(LEFT_BRACKET) || p.check(LEFT_BRACE) || p.check(LEFT_PAREN) || (p.check(IDENTIFIER) && p.checkNext(COMMA)) {
+		return p.parseDestructuringDeclaration()
+	}
+	identifier, err := p.consume(IDENTIFIER, "Expect variable name")
 	if err != nil {
 		return nil, err
 	}
+	annotation, err := p.parseOptionalType()
+	if err != nil {
+		return nil, err
+	}
+	var initValue Expression = CreateLiteral(Nil{})
 	if p.match(EQUAL) {
-		// Check if expr is var
-		exprVar, ok := expr.(*IdentifierExpr)
-		if !ok {
-			// If not then it must return error
-			return nil, p.CreateCompileError(p.peek(), "Invalid identifier")
-		}
-		value, err := p.parseComma()
+		expr, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
-		return CreateVarAssignment(exprVar.name, value), nil
+		initValue = expr
 	}
-	return expr, nil
+
+	if _, err := p.consume(SEMICOLON, "Missing semicolon ; at the end of statement"); err != nil {
+		return nil, err
+	}
+	declaration := CreateVarDeclaration(initValue, identifier)
+	declaration.Type = annotation
+	return declaration, nil
 }
 
-// Comma operator evaluates left side, discards it and then
-// evaluates and return right side.
-func (p *Parser) parseComma() (Expression, error) {
-	left, err := p.parseTernary()
+// `: type` or nothing
+func (p *Parser) parseOptionalType() (*TypeAnnotation, error) {
+	if !p.match(COLON) {
+		return nil, nil
+	}
+	if !p.match(IDENTIFIER, NIL) {
+		return nil, p.CreateCompileError(p.peek(), "Expected type name after ':'")
+	}
+	annotation := &TypeAnnotation{Name: p.previous()}
+	annotation.Nullable = p.match(QUESTION_MARK)
+	return annotation, nil
+}
+
+// const NAME = expr;
+func (p *Parser) parseConstDeclaration() (Statement, error) {
+	if p.check(LEFT_BRACKET) || p.check(LEFT_BRACE) || p.check(LEFT_PAREN) || (p.check(IDENTIFIER) && p.checkNext(COMMA)) {
+		return p.parseDestructuringDeclaration()
+	}
+	identifier, err := p.consume(IDENTIFIER, "Expect constant name")
+	if err != nil {
+		return nil, err
+	}
+	annotation, err := p.parseOptionalType()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(EQUAL) {
+		return nil, p.CreateCompileError(identifier, "Constant "+identifier.Lexeme+" must be initialized")
+	}
+	initValue, err := p.parseExpression()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(COMMA) {
-		p.previous()
-		right, err := p.parseTernary()
-		if err != nil {
-			return nil, err
-		}
-		left = right
+	if _, err := p.consume(SEMICOLON, "Missing semicolon ; at the end of statement"); err != nil {
+		return nil, err
 	}
-	return left, nil
+	declaration := CreateConstDeclaration(initValue, identifier)
+	declaration.Type = annotation
+	return declaration, nil
 }
 
-func (p *Parser) parseTernary() (Expression, error) {
-	expr, err := p.parseOr()
+// let [a, b, ...rest] = list; let {name, age: years} = map;
+func (p *Parser) parseDestructuringDeclaration() (Statement, error) {
+	keyword := p.previous()
+	pattern, err := p.parseSinglePattern()
 	if err != nil {
 		return nil, err
 	}
-	if p.match(QUESTION_MARK) {
+	if p.check(COMMA) {
+		// let q, r = divmod(7, 2);
+		elements := []Pattern{pattern}
+		for p.match(COMMA) {
+			element, err := p.parseSinglePattern()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		pattern = &TuplePattern{Elements: elements}
+	}
+	if !p.match(EQUAL) {
+		return nil, p.CreateCompileError(keyword, "Destructuring declaration must be initialized")
+	}
+	initValue, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.consume(SEMICOLON, "Missing semicolon ; at the end of statement"); err != nil {
+		return nil, err
+	}
+	return CreateDestructuringDeclaration(keyword, pattern, initValue), nil
+}
+
+func (p *Parser) parseFunctionDeclaration() (Statement, error) {
+	identifier, err := p.consume(IDENTIFIER, "Expected identifier after function declaration")
+	if err != nil {
+		return nil, err
+	}
+
+	declaration, err := p.parseFunction()
+	if err != nil || declaration == nil {
+		return nil, err
+	}
+	declaration.Identifier = identifier
+	return declaration, nil
+}
+
+func (p *Parser) parseFunctionExpression() (Statement, error) {
+	_, err := p.parseFunction()
+	if err != nil {
+		return nil, err
+	}
+	panic("TODO")
+}
+
+// Parses the params and body, the identifier is left to the caller
+func (p *Parser) parseFunction() (*FunctionDeclaration, error) {
+	_, err := p.consume(LEFT_PAREN, "Expected opening parentheses '(' in function declaration")
+	if err != nil {
+		return nil, err
+	}
+
+	params := []*Token{}
+	defaults := []Expression{}
+	paramTypes := []*TypeAnnotation{}
+	var rest *Token = nil
+	if !p.check(RIGHT_PAREN) {
+		for {
+			// fun log(level, ...parts)
+			if p.match(ELLIPSIS) {
+				rest, err = p.consume(IDENTIFIER, "Expected identifier after '...'")
+				if err != nil {
+					return nil, err
+				}
+				// Always a list
+				if p.check(COLON) {
+					return nil, p.CreateCompileError(rest, "Rest parameter can't be annotated")
+				}
+				if !p.check(RIGHT_PAREN) {
+					return nil, p.CreateCompileError(rest, "Rest parameter must be last")
+				}
+				break
+			}
+
+			param, err := p.consume(IDENTIFIER, "Expected identifier in parameter list")
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+
+			// fun f(a: string)
+			annotation, err := p.parseOptionalType()
+			if err != nil {
+				return nil, err
+			}
+			paramTypes = append(paramTypes, annotation)
+
+			// fun f(a, b = 10)
+			var defaultValue Expression = nil
+			if p.match(EQUAL) {
+				defaultValue, err = p.parseTernary()
+				if err != nil {
+					return nil, err
+				}
+			} else if len(defaults) > 0 && defaults[len(defaults)-1] != nil {
+				return nil, p.CreateCompileError(param, "Required parameter "+param.Lexeme+" can't follow a parameter with a default value")
+			}
+			defaults = append(defaults, defaultValue)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	if len(params) >= MAX_ARGS {
+		p.Lox.Error(p.peek(), fmt.Sprintf("Can't have more than %d args", MAX_ARGS))
+		return nil, nil
+	}
+
+	_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')'")
+	if err != nil {
+		return nil, err
+	}
+
+	// fun f(): bool
+	returnType, err := p.parseOptionalType()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(LEFT_BRACE, "Expected opening braces '{' in function declaration")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	declaration := CreateFunctionDeclaration(nil, params, defaults, rest, stmt)
+	declaration.ParamTypes, declaration.ReturnType = paramTypes, returnType
+	return declaration, nil
+}
+
+// enum Name { Variant, Variant(param, ...), ... }
+func (p *Parser) parseEnumDeclaration() (Statement, error) {
+	identifier, err := p.consume(IDENTIFIER, "Expected enum name")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LEFT_BRACE, "Expected opening brace '{' after enum name")
+	if err != nil {
+		return nil, err
+	}
+
+	variants := []*VariantDeclaration{}
+	for !p.check(RIGHT_BRACE) {
+		name, err := p.consume(IDENTIFIER, "Expected variant name")
+		if err != nil {
+			return nil, err
+		}
+		for _, variant := range variants {
+			if variant.Name.Lexeme == name.Lexeme {
+				return nil, p.CreateCompileError(name, "Duplicate variant "+name.Lexeme+" in enum "+identifier.Lexeme)
+			}
+		}
+
+		variant := &VariantDeclaration{Name: name}
+		if p.match(LEFT_PAREN) {
+			variant.Params = []*Token{}
+			for !p.check(RIGHT_PAREN) {
+				param, err := p.consume(IDENTIFIER, "Expected associated value name")
+				if err != nil {
+					return nil, err
+				}
+				for _, other := range variant.Params {
+					if other.Lexeme == param.Lexeme {
+						return nil, p.CreateCompileError(param, "Duplicate associated value "+param.Lexeme+" in variant "+name.Lexeme)
+					}
+				}
+				variant.Params = append(variant.Params, param)
+				if !p.match(COMMA) {
+					break
+				}
+			}
+			_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')' after associated values")
+			if err != nil {
+				return nil, err
+			}
+			if len(variant.Params) == 0 {
+				return nil, p.CreateCompileError(name, "Variant "+name.Lexeme+" needs at least one associated value")
+			}
+		}
+		variants = append(variants, variant)
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	_, err = p.consume(RIGHT_BRACE, "Expected closing brace '}' after enum variants")
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) == 0 {
+		return nil, p.CreateCompileError(identifier, "Enum needs at least one variant")
+	}
+	return CreateEnumDeclaration(identifier, variants), nil
+}
+
+func (p *Parser) parsePrint() (Statement, error) {
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expected ; after expression")
+	if err != nil {
+		return nil, err
+	}
+	return CreatePrintStatement(expr), nil
+}
+func (p *Parser) parseExpressionStatement() (Statement, error) {
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(LEFT_ARROW) {
+		return p.parseSend(expr)
+	}
+
+	_, err = p.consume(SEMICOLON, "Expected ; after expression: "+p.peek().Lexeme)
+	if err != nil {
+		return nil, err
+	}
+	return CreateExpressionStatement(expr), nil
+}
+
+func (p *Parser) parseBreak() (Statement, error) {
+	breakStmt := CreateBreakStatement()
+	_, err := p.consume(SEMICOLON, "Expected semicolon")
+	if err != nil {
+		return nil, err
+	}
+	return breakStmt, nil
+}
+
+func (p *Parser) parseReturn() (Statement, error) {
+	var expr Expression = nil
+	var err error = nil
+
+	if p.peek().Type != SEMICOLON {
+		expr, err = p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := p.consume(SEMICOLON, "Expected ; after expression: "+p.peek().Lexeme)
+	if err != nil {
+		return nil, err
+	}
+	return CreateReturnStatement(token, expr), nil
+}
+
+func (p *Parser) parseYield() (Statement, error) {
+	keyword := p.previous()
+	var expr Expression = CreateLiteral(nil)
+	if !p.check(SEMICOLON) {
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		expr = value
+	}
+
+	_, err := p.consume(SEMICOLON, "Expected ; after expression: "+p.peek().Lexeme)
+	if err != nil {
+		return nil, err
+	}
+	return CreateYieldStatement(keyword, expr), nil
+}
+
+func (p *Parser) parseExpression() (Expression, error) {
+	return p.parseAssignment()
+}
+
+// a = 4;
+func (p *Parser) parseAssignment() (Expression, error) {
+	expr, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(EQUAL) {
+		equal := p.previous()
+		switch expr.(type) {
+		case *ListLiteral, *TupleLiteral:
+			// [a, b] = [b, a] or a, b = b, a
+			pattern, err := p.assignmentTarget(expr)
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.parseComma()
+			if err != nil {
+				return nil, err
+			}
+			return CreateDestructuringAssignment(equal, pattern, value), nil
+		}
+
+		// Check if expr is var
+		exprVar, ok := expr.(*IdentifierExpr)
+		if !ok {
+			// If not then it must return error
+			return nil, p.CreateCompileError(p.peek(), "Invalid identifier")
+		}
+		value, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		return CreateVarAssignment(exprVar.name, value), nil
+	}
+	return expr, nil
+}
+
+// Turns the left side of a destructuring assignment into a pattern
+func (p *Parser) assignmentTarget(expr Expression) (Pattern, error) {
+	switch expr := expr.(type) {
+	case *IdentifierExpr:
+		if expr.name.Lexeme == "_" {
+			return &WildcardPattern{}, nil
+		}
+		return &BindingPattern{Name: expr.name}, nil
+	case *ListLiteral:
+		elements := []Pattern{}
+		var rest Pattern = nil
+		for idx, element := range expr.Elements {
+			// [first, ...others] = list
+			if spread, ok := element.(*Spread); ok && idx == len(expr.Elements)-1 {
+				target, err := p.assignmentTarget(spread.Expr)
+				if err != nil {
+					return nil, err
+				}
+				rest = target
+				break
+			}
+			target, err := p.assignmentTarget(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, target)
+		}
+		return &ListPattern{Elements: elements, Rest: rest}, nil
+	case *TupleLiteral:
+		elements := []Pattern{}
+		for _, element := range expr.Elements {
+			target, err := p.assignmentTarget(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, target)
+		}
+		return &TuplePattern{Elements: elements}, nil
+	case *MapLiteral:
+		keys, values := []any{}, []Pattern{}
+		for idx, key := range expr.Keys {
+			literal, ok := key.(*Literal)
+			if !ok {
+				return nil, p.CreateCompileError(expr.Brace, "Map keys must be constants in a destructuring assignment")
+			}
+			target, err := p.assignmentTarget(expr.Values[idx])
+			if err != nil {
+				return nil, err
+			}
+			keys, values = append(keys, literal.Value), append(values, target)
+		}
+		return &MapPattern{Keys: keys, Values: values}, nil
+	}
+	return nil, p.CreateCompileError(p.previous(), "Invalid destructuring assignment target")
+}
+
+// Commas build a tuple of the values on both sides : `return q, r;`
+func (p *Parser) parseComma() (Expression, error) {
+	left, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.check(COMMA) {
+		return left, nil
+	}
+
+	elements := []Expression{left}
+	for p.match(COMMA) {
+		if p.check(RIGHT_PAREN) {
+			// (x,)
+			break
+		}
+		right, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, right)
+	}
+	return CreateTupleLiteral(elements), nil
+}
+
+func (p *Parser) parseTernary() (Expression, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(QUESTION_MARK) {
 		left, err := p.parseTernary()
 		if err != nil {
 			return nil, err
@@ -472,13 +1091,13 @@ func (p *Parser) parseEquality() (Expression, error) {
 
 // (>, <, <=, >=)
 func (p *Parser) parseComparison() (Expression, error) {
-	left, err := p.parseTerm()
+	left, err := p.parseRange()
 	if err != nil {
 		return nil, err
 	}
-	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
+	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL, IN) {
 		operator := p.previous()
-		right, err := p.parseTerm()
+		right, err := p.parseRange()
 		if err != nil {
 			return nil, err
 		}
@@ -488,6 +1107,35 @@ func (p *Parser) parseComparison() (Expression, error) {
 }
 
 // (+, -)
+// a..b, a..=b, optionally followed by `step s`
+func (p *Parser) parseRange() (Expression, error) {
+	start, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(DOT_DOT, DOT_DOT_EQUAL) {
+		return start, nil
+	}
+	operator := p.previous()
+	end, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	var step Expression = nil
+	if p.check(IDENTIFIER) && p.peek().Lexeme == "step" {
+		p.advance()
+		step, err = p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.check(DOT_DOT) || p.check(DOT_DOT_EQUAL) {
+		return nil, p.CreateCompileError(p.peek(), "Ranges can't be chained")
+	}
+	return CreateRangeLiteral(start, operator, end, step), nil
+}
+
 func (p *Parser) parseTerm() (Expression, error) {
 	left, err := p.parseFactor()
 	if err != nil {
@@ -531,6 +1179,26 @@ func (p *Parser) parseUnary() (Expression, error) {
 		}
 		return CreateUnary(right, operand), nil
 	}
+	if p.match(LEFT_ARROW) {
+		arrow := p.previous()
+		channel, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return CreateReceive(arrow, channel), nil
+	}
+	if p.match(SPAWN) {
+		keyword := p.previous()
+		call, err := p.parseFunctionCall()
+		if err != nil {
+			return nil, err
+		}
+		function, ok := call.(*Function)
+		if !ok {
+			return nil, p.CreateCompileError(keyword, "Expected a call after spawn")
+		}
+		return CreateSpawn(keyword, function), nil
+	}
 	return p.parseFunctionCall()
 }
 
@@ -540,17 +1208,41 @@ func (p *Parser) parseFunctionCall() (Expression, error) {
 	if err != nil {
 		return nil, err
 	}
-	for p.match(LEFT_PAREN) {
+	for p.check(LEFT_PAREN) || p.check(LEFT_BRACKET) || p.check(DOT) {
+		if p.match(DOT) {
+			name, err := p.consume(IDENTIFIER, "Expected property name after '.'")
+			if err != nil {
+				return nil, err
+			}
+			identifier = CreateProperty(identifier, name)
+			token = name
+			continue
+		}
+		if p.match(LEFT_BRACKET) {
+			bracket := p.previous()
+			index, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.consume(RIGHT_BRACKET, "Expected closing bracket ']'")
+			if err != nil {
+				return nil, err
+			}
+			identifier = CreateIndex(identifier, index, bracket)
+			continue
+		}
+
+		p.advance()
 		args := []Expression{}
 		if !p.check(RIGHT_PAREN) {
-			expr, err := p.parseTernary()
+			expr, err := p.parseArgument(args)
 			if err != nil {
 				return nil, err
 			}
 
 			args = append(args, expr)
 			for p.match(COMMA) {
-				expr, err = p.parseTernary()
+				expr, err = p.parseArgument(args)
 				if err != nil {
 					return nil, err
 				}
@@ -567,6 +1259,30 @@ func (p *Parser) parseFunctionCall() (Expression, error) {
 	return identifier, nil
 }
 
+// Either `expr`, `...expr` or `name: expr`, named arguments coming last
+func (p *Parser) parseArgument(previous []Expression) (Expression, error) {
+	if p.check(IDENTIFIER) && p.checkNext(COLON) {
+		name := p.advance()
+		p.advance()
+		value, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		return CreateNamedArgument(name, value), nil
+	}
+
+	expr, err := p.parseSpreadable()
+	if err != nil {
+		return nil, err
+	}
+	if len(previous) > 0 {
+		if named, ok := previous[len(previous)-1].(*NamedArgument); ok {
+			return nil, p.CreateCompileError(named.Name, "Positional argument can't follow a named argument")
+		}
+	}
+	return expr, nil
+}
+
 func (p *Parser) parsePrimary() (Expression, error) {
 	if p.match(STRING, NUMBER, TRUE, FALSE, NIL, CHAR) {
 		cur := p.previous()
@@ -574,8 +1290,19 @@ func (p *Parser) parsePrimary() (Expression, error) {
 	} else if p.match(IDENTIFIER) {
 		cur := p.previous()
 		return CreateIdentifier(cur), nil
+	} else if p.match(INTERPOLATION) {
+		return p.parseInterpolation()
+	} else if p.match(LEFT_BRACKET) {
+		return p.parseListLiteral()
+	} else if p.match(LEFT_BRACE) {
+		return p.parseMapLiteral()
+	} else if p.match(MATCH) {
+		return p.parseMatch()
 	} else {
 		if p.match(LEFT_PAREN) {
+			if p.match(RIGHT_PAREN) {
+				return CreateTupleLiteral([]Expression{}), nil
+			}
 			expr, err := p.parseExpression()
 			if err != nil {
 				return nil, err
@@ -583,14 +1310,346 @@ func (p *Parser) parsePrimary() (Expression, error) {
 			_, err = p.consume(RIGHT_PAREN, "Expected )")
 			if err != nil {
 				return nil, err
-			} else {
-				return CreateGroup(expr), nil
 			}
+			if tuple, ok := expr.(*TupleLiteral); ok {
+				return tuple, nil
+			}
+			return CreateGroup(expr), nil
 		}
 	}
 	return nil, p.CreateCompileError(p.peek(), "Unknown symbol '"+p.peek().Lexeme+"'")
 }
 
+// The INTERPOLATION token has already been consumed
+func (p *Parser) parseInterpolation() (Expression, error) {
+	parts := []Expression{}
+	for {
+		parts = append(parts, CreateLiteral(p.previous().Literal))
+		expr, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+		if !p.match(INTERPOLATION) {
+			break
+		}
+	}
+
+	end, err := p.consume(STRING, "Expected '}' after interpolated expression")
+	if err != nil {
+		return nil, err
+	}
+	parts = append(parts, CreateLiteral(end.Literal))
+	return CreateInterpolation(parts), nil
+}
+
+// [1, 2, 3]
+func (p *Parser) parseListLiteral() (Expression, error) {
+	elements := []Expression{}
+	for !p.check(RIGHT_BRACKET) {
+		element, err := p.parseSpreadable()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	_, err := p.consume(RIGHT_BRACKET, "Expected closing bracket ']' after list elements")
+	if err != nil {
+		return nil, err
+	}
+	return CreateListLiteral(elements), nil
+}
+
+// An expression optionally prefixed by `...`
+func (p *Parser) parseSpreadable() (Expression, error) {
+	if p.match(ELLIPSIS) {
+		token := p.previous()
+		expr, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		return CreateSpread(token, expr), nil
+	}
+	return p.parseTernary()
+}
+
+// {name: "wes", "key": 1} : bare identifiers are string keys
+func (p *Parser) parseMapLiteral() (Expression, error) {
+	brace := p.previous()
+	keys, values := []Expression{}, []Expression{}
+	for !p.check(RIGHT_BRACE) {
+		var key Expression
+		if p.check(IDENTIFIER) && p.checkNext(COLON) {
+			key = CreateLiteral(p.advance().Lexeme)
+		} else {
+			expr, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			key = expr
+		}
+
+		_, err := p.consume(COLON, "Expected ':' after map key")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		keys, values = append(keys, key), append(values, value)
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	_, err := p.consume(RIGHT_BRACE, "Expected closing brace '}' after map entries")
+	if err != nil {
+		return nil, err
+	}
+	return CreateMapLiteral(keys, values, brace), nil
+}
+
+// match (value) { pattern [if guard] => expr, ... }
+func (p *Parser) parseMatch() (Expression, error) {
+	keyword := p.previous()
+	_, err := p.consume(LEFT_PAREN, "Expected opening parentheses '(' after match")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')'")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LEFT_BRACE, "Expected opening brace '{' after match value")
+	if err != nil {
+		return nil, err
+	}
+
+	arms := []*MatchArm{}
+	for !p.check(RIGHT_BRACE) {
+		pattern, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+
+		var guard Expression = nil
+		if p.match(IF) {
+			guard, err = p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		_, err = p.consume(ARROW, "Expected '=>' after pattern")
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+
+		arms = append(arms, &MatchArm{Pattern: pattern, Guard: guard, Body: body})
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	_, err = p.consume(RIGHT_BRACE, "Expected closing brace '}' after match arms")
+	if err != nil {
+		return nil, err
+	}
+	if len(arms) == 0 {
+		return nil, p.CreateCompileError(keyword, "Match needs at least one arm")
+	}
+	return CreateMatch(keyword, value, arms), nil
+}
+
+// pattern ( "|" pattern )*
+func (p *Parser) parsePattern() (Pattern, error) {
+	pattern, err := p.parseSinglePattern()
+	if err != nil {
+		return nil, err
+	}
+	if !p.check(PIPE) {
+		return pattern, nil
+	}
+
+	pipe := p.peek()
+	alternatives := []Pattern{pattern}
+	for p.match(PIPE) {
+		alternative, err := p.parseSinglePattern()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	return &AlternativePattern{Alternatives: alternatives, Pipe: pipe}, nil
+}
+
+func (p *Parser) parseSinglePattern() (Pattern, error) {
+	if p.check(IDENTIFIER) && p.checkNext(DOT) {
+		return p.parseEnumPattern()
+	}
+	if p.match(IDENTIFIER) {
+		if p.previous().Lexeme == "_" {
+			return &WildcardPattern{}, nil
+		}
+		return &BindingPattern{Name: p.previous()}, nil
+	}
+	if p.match(STRING, NUMBER, TRUE, FALSE, NIL, CHAR) {
+		return &LiteralPattern{Value: CreateLiteral(p.previous().Literal)}, nil
+	}
+	if p.match(MINUS) {
+		operand := p.previous()
+		number, err := p.consume(NUMBER, "Expected number after '-' in pattern")
+		if err != nil {
+			return nil, err
+		}
+		return &LiteralPattern{Value: CreateUnary(CreateLiteral(number.Literal), operand)}, nil
+	}
+
+	if p.match(LEFT_PAREN) {
+		elements := []Pattern{}
+		trailingComma := false
+		for !p.check(RIGHT_PAREN) {
+			element, err := p.parsePattern()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			trailingComma = p.match(COMMA)
+			if !trailingComma {
+				break
+			}
+		}
+		_, err := p.consume(RIGHT_PAREN, "Expected closing parentheses ')' in tuple pattern")
+		if err != nil {
+			return nil, err
+		}
+		if len(elements) == 1 && !trailingComma {
+			// Grouping
+			return elements[0], nil
+		}
+		return &TuplePattern{Elements: elements}, nil
+	}
+
+	if p.match(LEFT_BRACKET) {
+		elements := []Pattern{}
+		var rest Pattern = nil
+		for !p.check(RIGHT_BRACKET) {
+			if p.match(ELLIPSIS) {
+				name, err := p.consume(IDENTIFIER, "Expected identifier after '...'")
+				if err != nil {
+					return nil, err
+				}
+				rest = &BindingPattern{Name: name}
+				if name.Lexeme == "_" {
+					rest = &WildcardPattern{}
+				}
+				p.match(COMMA)
+				if !p.check(RIGHT_BRACKET) {
+					return nil, p.CreateCompileError(name, "Rest element must be last in list pattern")
+				}
+				break
+			}
+			element, err := p.parsePattern()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+		_, err := p.consume(RIGHT_BRACKET, "Expected closing bracket ']' in list pattern")
+		if err != nil {
+			return nil, err
+		}
+		return &ListPattern{Elements: elements, Rest: rest}, nil
+	}
+
+	if p.match(LEFT_BRACE) {
+		keys, values := []any{}, []Pattern{}
+		for !p.check(RIGHT_BRACE) {
+			if !p.match(IDENTIFIER, STRING, NUMBER, TRUE, FALSE, NIL, CHAR) {
+				return nil, p.CreateCompileError(p.peek(), "Expected key in map pattern")
+			}
+			key := p.previous().Literal
+			if p.previous().Type == IDENTIFIER {
+				key = p.previous().Lexeme
+				if !p.check(COLON) {
+					// {name} is short for {name: name}
+					keys, values = append(keys, key), append(values, &BindingPattern{Name: p.previous()})
+					if !p.match(COMMA) {
+						break
+					}
+					continue
+				}
+			}
+			_, err := p.consume(COLON, "Expected ':' after key in map pattern")
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.parsePattern()
+			if err != nil {
+				return nil, err
+			}
+			keys, values = append(keys, key), append(values, value)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+		_, err := p.consume(RIGHT_BRACE, "Expected closing brace '}' in map pattern")
+		if err != nil {
+			return nil, err
+		}
+		return &MapPattern{Keys: keys, Values: values}, nil
+	}
+
+	return nil, p.CreateCompileError(p.peek(), "Invalid pattern '"+p.peek().Lexeme+"'")
+}
+
+// Enum.Variant or Enum.Variant(pattern, ...)
+func (p *Parser) parseEnumPattern() (Pattern, error) {
+	enum := p.advance()
+	p.advance()
+	variant, err := p.consume(IDENTIFIER, "Expected variant name after '.' in pattern")
+	if err != nil {
+		return nil, err
+	}
+	pattern := &EnumPattern{Enum: CreateIdentifier(enum), Variant: variant}
+	if !p.match(LEFT_PAREN) {
+		return pattern, nil
+	}
+
+	pattern.Values = []Pattern{}
+	for !p.check(RIGHT_PAREN) {
+		value, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		pattern.Values = append(pattern.Values, value)
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	_, err = p.consume(RIGHT_PAREN, "Expected closing parentheses ')' in enum pattern")
+	if err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
 func (p *Parser) isAtEnd() bool {
 	return p.peek().Type == EOF
 }
@@ -623,6 +1682,13 @@ func (p *Parser) check(expr TokenType) bool {
 	return p.Tokens[p.Current].Type == expr
 }
 
+func (p *Parser) checkNext(expr TokenType) bool {
+	if p.isAtEnd() || p.Tokens[p.Current+1].Type == EOF {
+		return false
+	}
+	return p.Tokens[p.Current+1].Type == expr
+}
+
 func (p *Parser) advance() *Token {
 	if !p.isAtEnd() {
 		p.Current += 1
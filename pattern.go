@@ -0,0 +1,374 @@
+package main
+
+import "fmt"
+
+// Patterns are the left side of a `match` arm
+type Pattern interface {
+	// Identifiers bound when the pattern matches, in order
+	names() []*Token
+}
+
+// _
+type WildcardPattern struct{}
+
+func (w *WildcardPattern) names() []*Token {
+	return nil
+}
+
+// x : matches anything and binds it to x
+type BindingPattern struct {
+	Name *Token
+}
+
+func (b *BindingPattern) names() []*Token {
+	return []*Token{b.Name}
+}
+
+// 1, -1, "a", true, nil, 'c'
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (l *LiteralPattern) names() []*Token {
+	return nil
+}
+
+// "a" | "b" : every alternative binds the same names
+type AlternativePattern struct {
+	Alternatives []Pattern
+	Pipe         *Token
+}
+
+func (a *AlternativePattern) names() []*Token {
+	return a.Alternatives[0].names()
+}
+
+// [x, y] : matches lists of exactly that length
+// [x, ...rest] : matches lists of at least that length, rest being a list
+type ListPattern struct {
+	Elements []Pattern
+	// nil without `...`
+	Rest Pattern
+}
+
+func (l *ListPattern) names() []*Token {
+	names := []*Token{}
+	for _, element := range l.Elements {
+		names = append(names, element.names()...)
+	}
+	if l.Rest != nil {
+		names = append(names, l.Rest.names()...)
+	}
+	return names
+}
+
+// (x, y) : matches tuples of exactly that length, `let x, y = ...` being
+// short for `let (x, y) = ...`
+type TuplePattern struct {
+	Elements []Pattern
+}
+
+func (t *TuplePattern) names() []*Token {
+	names := []*Token{}
+	for _, element := range t.Elements {
+		names = append(names, element.names()...)
+	}
+	return names
+}
+
+// {name: n, age} : matches maps having at least these keys, `age` being
+// short for `age: age`
+type MapPattern struct {
+	Keys   []any
+	Values []Pattern
+}
+
+func (m *MapPattern) names() []*Token {
+	names := []*Token{}
+	for _, value := range m.Values {
+		names = append(names, value.names()...)
+	}
+	return names
+}
+
+// Color.Red : matches a variant of an enum
+// Option.Some(x) : also matches its associated values, `Option.Some` alone
+// matching whatever they are
+type EnumPattern struct {
+	Enum    *IdentifierExpr
+	Variant *Token
+	// nil without parentheses
+	Values []Pattern
+}
+
+func (e *EnumPattern) names() []*Token {
+	names := []*Token{}
+	for _, value := range e.Values {
+		names = append(names, value.names()...)
+	}
+	return names
+}
+
+func (i *Interpreter) VisitMatch(m *Match) (any, error) {
+	value, err := i.evaluate(m.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range m.Arms {
+		bound := map[string]any{}
+		matched, err := i.matchPattern(arm.Pattern, value, bound)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		matched, val, err := i.evaluateArm(arm, bound)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return val, nil
+		}
+	}
+	return nil, CreateRuntimeError(m.Keyword, "No arm matches "+stringifyNested(value))
+}
+
+// Runs the guard and body of an arm in a scope holding the pattern bindings.
+// Returns false when the guard fails.
+func (i *Interpreter) evaluateArm(arm *MatchArm, bound map[string]any) (bool, any, error) {
+	prevEnv := i.Environment
+	defer func() {
+		i.Environment = prevEnv
+	}()
+
+	i.Environment = CreateEnvironment(prevEnv, i)
+	for _, name := range arm.Pattern.names() {
+		i.Environment.Set(name.Lexeme, bound[name.Lexeme])
+	}
+
+	if arm.Guard != nil {
+		guard, err := i.evaluate(arm.Guard)
+		if err != nil {
+			return false, nil, err
+		}
+		if !i.isTruthy(guard) {
+			return false, nil, nil
+		}
+	}
+
+	val, err := i.evaluate(arm.Body)
+	return true, val, err
+}
+
+func (i *Interpreter) matchPattern(pattern Pattern, value any, bound map[string]any) (bool, error) {
+	switch pattern := pattern.(type) {
+	case *WildcardPattern:
+		return true, nil
+
+	case *BindingPattern:
+		bound[pattern.Name.Lexeme] = value
+		return true, nil
+
+	case *LiteralPattern:
+		literal, err := i.evaluate(pattern.Value)
+		if err != nil {
+			return false, err
+		}
+		return i.isEqual(literal, value), nil
+
+	case *AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			altBound := map[string]any{}
+			matched, err := i.matchPattern(alternative, value, altBound)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				for name, val := range altBound {
+					bound[name] = val
+				}
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case *ListPattern:
+		list, ok := value.(*List)
+		if !ok || len(list.Elements) < len(pattern.Elements) {
+			return false, nil
+		}
+		if pattern.Rest == nil && len(list.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		for idx, element := range pattern.Elements {
+			matched, err := i.matchPattern(element, list.Elements[idx], bound)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		if pattern.Rest != nil {
+			rest := CreateList(append([]any{}, list.Elements[len(pattern.Elements):]...))
+			return i.matchPattern(pattern.Rest, rest, bound)
+		}
+		return true, nil
+
+	case *TuplePattern:
+		tuple, ok := value.(*Tuple)
+		if !ok || len(tuple.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		for idx, element := range pattern.Elements {
+			matched, err := i.matchPattern(element, tuple.Elements[idx], bound)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case *MapPattern:
+		m, ok := value.(*Map)
+		if !ok {
+			return false, nil
+		}
+		for idx, key := range pattern.Keys {
+			val, found := m.Get(key)
+			if !found {
+				return false, nil
+			}
+			matched, err := i.matchPattern(pattern.Values[idx], val, bound)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case *EnumPattern:
+		variant, err := i.lookUpVariant(pattern)
+		if err != nil {
+			return false, err
+		}
+		enumValue, ok := value.(*EnumValue)
+		if !ok || enumValue.Variant != variant {
+			return false, nil
+		}
+		for idx, element := range pattern.Values {
+			matched, err := i.matchPattern(element, enumValue.Values[idx], bound)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	panic("Unreachable")
+}
+
+// Like matchPattern but explains why the value doesn't fit the pattern
+func (i *Interpreter) destructure(token *Token, pattern Pattern, value any, bound map[string]any) error {
+	switch pattern := pattern.(type) {
+	case *ListPattern:
+		list, ok := value.(*List)
+		if !ok {
+			return CreateRuntimeError(token, "Cannot destructure "+stringifyNested(value)+" as a list")
+		}
+		if pattern.Rest == nil && len(list.Elements) != len(pattern.Elements) {
+			return CreateRuntimeError(token, fmt.Sprintf("Expected a list of %d elements but got %d", len(pattern.Elements), len(list.Elements)))
+		}
+		if len(list.Elements) < len(pattern.Elements) {
+			return CreateRuntimeError(token, fmt.Sprintf("Expected a list of at least %d elements but got %d", len(pattern.Elements), len(list.Elements)))
+		}
+		for idx, element := range pattern.Elements {
+			if err := i.destructure(token, element, list.Elements[idx], bound); err != nil {
+				return err
+			}
+		}
+		if pattern.Rest != nil {
+			rest := CreateList(append([]any{}, list.Elements[len(pattern.Elements):]...))
+			return i.destructure(token, pattern.Rest, rest, bound)
+		}
+		return nil
+
+	case *TuplePattern:
+		tuple, ok := value.(*Tuple)
+		if !ok {
+			return CreateRuntimeError(token, "Cannot destructure "+stringifyNested(value)+" as a tuple")
+		}
+		if len(tuple.Elements) != len(pattern.Elements) {
+			return CreateRuntimeError(token, fmt.Sprintf("Expected a tuple of %d elements but got %d", len(pattern.Elements), len(tuple.Elements)))
+		}
+		for idx, element := range pattern.Elements {
+			if err := i.destructure(token, element, tuple.Elements[idx], bound); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *MapPattern:
+		m, ok := value.(*Map)
+		if !ok {
+			return CreateRuntimeError(token, "Cannot destructure "+stringifyNested(value)+" as a map")
+		}
+		for idx, key := range pattern.Keys {
+			val, found := m.Get(key)
+			if !found {
+				return CreateRuntimeError(token, "Missing key "+stringifyNested(key)+" in "+stringifyNested(value))
+			}
+			if err := i.destructure(token, pattern.Values[idx], val, bound); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	matched, err := i.matchPattern(pattern, value, bound)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return CreateRuntimeError(token, stringifyNested(value)+" doesn't match the pattern")
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitDestructuringDeclaration(d *DestructuringDeclaration) (any, error) {
+	value, err := i.evaluate(d.Expr)
+	if err != nil {
+		return nil, err
+	}
+	bound := map[string]any{}
+	if err := i.destructure(d.Keyword, d.Pattern, value, bound); err != nil {
+		return nil, err
+	}
+
+	for _, name := range d.Pattern.names() {
+		if _, err := i.Environment.GetCurrentBlock(name.Lexeme); err == nil {
+			return nil, CreateRuntimeError(name, "Redeclaration of name "+name.Lexeme)
+		}
+		if d.Constant {
+			i.Environment.SetConstant(name.Lexeme, bound[name.Lexeme])
+		} else {
+			i.Environment.Set(name.Lexeme, bound[name.Lexeme])
+		}
+	}
+	return value, nil
+}
+
+func (i *Interpreter) VisitDestructuringAssignment(d *DestructuringAssignment) (any, error) {
+	value, err := i.evaluate(d.Expr)
+	if err != nil {
+		return nil, err
+	}
+	bound := map[string]any{}
+	if err := i.destructure(d.Token, d.Pattern, value, bound); err != nil {
+		return nil, err
+	}
+
+	for _, assignment := range d.Assignments {
+		if err := i.assign(assignment, bound[assignment.Token.Lexeme]); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// `a..b` counts from a up to b excluded, `a..=b` includes b, and
+// `a..b step s` counts by s, downward when s is negative. Ranges are lazy :
+// they only hold their bounds, so iterating doesn't build a list and `in`
+// takes constant time.
+//
+// Besides for-in, `in`, len and spreads, ranges slice lists, tuples and strings :
+// `xs[1..3]` holds the elements at indices 1 and 2.
+type Range struct {
+	Start     int64
+	End       int64
+	Step      int64
+	Inclusive bool
+}
+
+// Offset of the last value from Start and the magnitude of the step, in
+// uint64 as both overflow int64 for the widest ranges. ok is false for an
+// empty range
+func (r *Range) bounds() (last uint64, step uint64, ok bool) {
+	var span uint64
+	if r.Step > 0 {
+		if r.Start > r.End || (r.Start == r.End && !r.Inclusive) {
+			return 0, 0, false
+		}
+		span, step = uint64(r.End)-uint64(r.Start), uint64(r.Step)
+	} else {
+		if r.Start < r.End || (r.Start == r.End && !r.Inclusive) {
+			return 0, 0, false
+		}
+		span, step = uint64(r.Start)-uint64(r.End), -uint64(r.Step)
+	}
+	if !r.Inclusive {
+		span -= 1
+	}
+	return span - span%step, step, true
+}
+
+// Number of values, 0 for an empty range. ok is false when it doesn't fit
+// an int64
+func (r *Range) length() (int64, bool) {
+	last, step, ok := r.bounds()
+	if !ok {
+		return 0, true
+	}
+	if last/step >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(last/step) + 1, true
+}
+
+// Number of values for len, a bigint when it doesn't fit an int64
+func (r *Range) count() any {
+	if length, ok := r.length(); ok {
+		return length
+	}
+	last, step, _ := r.bounds()
+	return new(big.Int).Add(new(big.Int).SetUint64(last/step), big.NewInt(1))
+}
+
+// Value at offset from Start, wrapping around like the offset did
+func (r *Range) at(offset uint64) int64 {
+	if r.Step > 0 {
+		return int64(uint64(r.Start) + offset)
+	}
+	return int64(uint64(r.Start) - offset)
+}
+
+// Numbers equal to an integer of the range, such as 2.0, are in it
+func (r *Range) contains(value any) bool {
+	normalized, _ := mapKey(value)
+	n, ok := normalized.(int64)
+	if !ok {
+		return false
+	}
+	last, step, ok := r.bounds()
+	if !ok || (r.Step > 0 && n < r.Start) || (r.Step < 0 && n > r.Start) {
+		return false
+	}
+	offset := uint64(n) - uint64(r.Start)
+	if r.Step < 0 {
+		offset = uint64(r.Start) - uint64(n)
+	}
+	return offset <= last && offset%step == 0
+}
+
+func (r *Range) String() string {
+	operator := ".."
+	if r.Inclusive {
+		operator = "..="
+	}
+	str := fmt.Sprintf("%d%s%d", r.Start, operator, r.End)
+	if r.Step != 1 {
+		str += fmt.Sprintf(" step %d", r.Step)
+	}
+	return str
+}
+
+type rangeIterator struct {
+	r      *Range
+	offset uint64
+	done   bool
+}
+
+func (r *rangeIterator) next(i *Interpreter) (any, bool, error) {
+	last, step, ok := r.r.bounds()
+	if !ok || r.done {
+		return nil, false, nil
+	}
+	value := r.r.at(r.offset)
+	// Stepping past the last value could wrap around
+	if r.offset == last {
+		r.done = true
+	} else {
+		r.offset += step
+	}
+	return value, true, nil
+}
+
+func (i *Interpreter) VisitRangeLiteral(r *RangeLiteral) (any, error) {
+	bounds := []Expression{r.Start, r.End}
+	if r.Step != nil {
+		bounds = append(bounds, r.Step)
+	}
+	values := []int64{}
+	for _, bound := range bounds {
+		value, err := i.evaluate(bound)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := value.(int64)
+		if !ok {
+			return nil, CreateRuntimeError(r.Operator, "Range bounds must be integers but got "+stringifyNested(value))
+		}
+		values = append(values, n)
+	}
+
+	result := &Range{Start: values[0], End: values[1], Step: 1, Inclusive: r.Operator.Type == DOT_DOT_EQUAL}
+	if r.Step != nil {
+		result.Step = values[2]
+	}
+	if result.Step == 0 {
+		return nil, CreateRuntimeError(r.Operator, "Range step can't be 0")
+	}
+	return result, nil
+}
+
+// value in container
+func (i *Interpreter) contains(token *Token, value any, container any) (bool, error) {
+	switch container := container.(type) {
+	case *Range:
+		return container.contains(value), nil
+	case *List:
+		for _, element := range container.Elements {
+			if i.isEqual(value, element) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *Tuple:
+		return i.contains(token, value, CreateList(container.Elements))
+	case *Map:
+		_, found := container.Get(value)
+		return found, nil
+	case string:
+		str, ok := value.(string)
+		if !ok {
+			return false, CreateRuntimeError(token, "Only strings can be searched in a string, got "+stringifyNested(value))
+		}
+		return strings.Contains(container, str), nil
+	}
+	return false, CreateRuntimeError(token, "Cannot test membership in "+stringifyNested(container))
+}
+
+// Elements of a string, list or tuple at the indices of the range
+func (i *Interpreter) slice(token *Token, object any, r *Range) (any, error) {
+	var elements []any
+	switch object := object.(type) {
+	case string:
+		for _, char := range object {
+			elements = append(elements, string(char))
+		}
+	case *List:
+		elements = object.Elements
+	case *Tuple:
+		elements = object.Elements
+	default:
+		return nil, CreateRuntimeError(token, "Only strings, lists and tuples can be sliced")
+	}
+
+	capacity := len(elements)
+	if length, ok := r.length(); ok && length < int64(capacity) {
+		capacity = int(length)
+	}
+	sliced := make([]any, 0, capacity)
+	indices := &rangeIterator{r: r}
+	for {
+		idx, ok, _ := indices.next(i)
+		if !ok {
+			break
+		}
+		at, err := i.checkIndex(token, idx.(int64), len(elements))
+		if err != nil {
+			return nil, err
+		}
+		sliced = append(sliced, elements[at])
+	}
+
+	switch object.(type) {
+	case string:
+		var builder strings.Builder
+		for _, char := range sliced {
+			builder.WriteString(char.(string))
+		}
+		return builder.String(), nil
+	case *Tuple:
+		return CreateTuple(sliced), nil
+	}
+	return CreateList(sliced), nil
+}
@@ -6,11 +6,14 @@ type Resolver struct {
 	Scopes []*[]*ScopeValue
 
 	functionType FunctionType
+	// Innermost function being resolved, nil at the top level
+	function *FunctionDeclaration
 }
 
 type ScopeValue struct {
-	Token  *Token
-	Status Status
+	Token    *Token
+	Status   Status
+	Constant bool
 }
 
 // Status
@@ -50,6 +53,11 @@ func (r *Resolver) VisitVarDeclaration(v *VarDeclaration) (any, error) {
 	r.declare(v.Identifier)
 	r.resolveExpr(v.Expr)
 	r.define(v.Identifier)
+	if v.Constant && !r.isEmpty() {
+		cur := r.Scopes[len(r.Scopes)-1]
+		val, _ := r.findByName(*cur, v.Identifier.Lexeme)
+		val.Constant = true
+	}
 
 	return nil, nil
 }
@@ -58,19 +66,27 @@ func (r *Resolver) VisitFunctionDeclaration(f *FunctionDeclaration) (any, error)
 	r.declare(f.Identifier)
 	r.define(f.Identifier)
 
-	currFunctionType := r.functionType
-	r.functionType = FUNCTION
+	currFunctionType, currFunction := r.functionType, r.function
+	r.functionType, r.function = FUNCTION, f
 	defer func() {
-		r.functionType = currFunctionType
+		r.functionType, r.function = currFunctionType, currFunction
 	}()
 
 	r.beginScope()
 	defer r.endScope()
 
-	for _, param := range f.Params {
+	for idx, param := range f.Params {
+		// Defaults only see the params before them
+		if f.Defaults[idx] != nil {
+			r.resolveExpr(f.Defaults[idx])
+		}
 		r.declare(param)
 		r.define(param)
 	}
+	if f.Rest != nil {
+		r.declare(f.Rest)
+		r.define(f.Rest)
+	}
 
 	for _, stmt := range f.Stmts {
 		r.resolveStmt(stmt)
@@ -79,6 +95,17 @@ func (r *Resolver) VisitFunctionDeclaration(f *FunctionDeclaration) (any, error)
 	return nil, nil
 }
 
+func (r *Resolver) VisitEnumDeclaration(e *EnumDeclaration) (any, error) {
+	r.declare(e.Identifier)
+	r.define(e.Identifier)
+	if !r.isEmpty() {
+		cur := r.Scopes[len(r.Scopes)-1]
+		val, _ := r.findByName(*cur, e.Identifier.Lexeme)
+		val.Constant = true
+	}
+	return nil, nil
+}
+
 func (r *Resolver) VisitReturnStatement(ret *ReturnStatement) (any, error) {
 	if r.functionType == NONE {
 		r.Lox.Error(ret.Token, "Illegal return statement")
@@ -91,6 +118,17 @@ func (r *Resolver) VisitReturnStatement(ret *ReturnStatement) (any, error) {
 	return nil, nil
 }
 
+func (r *Resolver) VisitYieldStatement(y *YieldStatement) (any, error) {
+	if r.function == nil {
+		r.Lox.Error(y.Keyword, "Can't yield outside of a function")
+		return nil, nil
+	}
+	r.function.Generator = true
+	r.resolveExpr(y.Expr)
+
+	return nil, nil
+}
+
 func (r *Resolver) VisitIfStatement(i *IfStatement) (any, error) {
 	r.resolveExpr(i.Expr)
 
@@ -105,10 +143,101 @@ func (r *Resolver) VisitIfStatement(i *IfStatement) (any, error) {
 func (r *Resolver) VisitWhileStatement(w *WhileStatement) (any, error) {
 	r.resolveExpr(w.Expr)
 	r.resolveStmt(w.Stmt)
+	if w.Else != nil {
+		r.resolveStmt(w.Else)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitForInStatement(f *ForInStatement) (any, error) {
+	r.resolveExpr(f.Iterable)
+
+	r.beginScope()
+	for _, name := range f.Names {
+		r.declare(name)
+		r.define(name)
+	}
+	r.resolveStmt(f.Stmt)
+	r.endScope()
+
+	if f.Else != nil {
+		r.resolveStmt(f.Else)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitSendStatement(s *SendStatement) (any, error) {
+	r.resolveExpr(s.Channel)
+	r.resolveExpr(s.Value)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitSelectStatement(s *SelectStatement) (any, error) {
+	for _, selectCase := range s.Cases {
+		if selectCase.Channel != nil {
+			r.resolveExpr(selectCase.Channel)
+		}
+		if selectCase.Value != nil {
+			r.resolveExpr(selectCase.Value)
+		}
+
+		r.beginScope()
+		if selectCase.Name != nil {
+			r.declare(selectCase.Name)
+			r.define(selectCase.Name)
+		}
+		r.resolveStmt(selectCase.Body)
+		r.endScope()
+	}
 
 	return nil, nil
 }
 
+func (r *Resolver) VisitSwitchStatement(s *SwitchStatement) (any, error) {
+	r.resolveExpr(s.Value)
+
+	constants := []any{}
+	for _, switchCase := range s.Cases {
+		for _, label := range switchCase.Labels {
+			r.resolveExpr(label)
+
+			value, ok := r.constantValue(label)
+			if !ok {
+				continue
+			}
+			for _, seen := range constants {
+				if r.Interpreter.isEqual(seen, value) {
+					r.Lox.Warn(switchCase.Token, "Duplicate case label "+stringifyNested(value))
+					break
+				}
+			}
+			constants = append(constants, value)
+		}
+		r.resolveStmt(switchCase.Body)
+	}
+
+	return nil, nil
+}
+
+// Value of literals and negated number literals
+func (r *Resolver) constantValue(expr Expression) (any, bool) {
+	switch expr := expr.(type) {
+	case *Literal:
+		return expr.Value, true
+	case *Unary:
+		if _, isLiteral := expr.Right.(*Literal); !isLiteral || expr.Operand.Type != MINUS {
+			return nil, false
+		}
+		value, err := r.Interpreter.evaluate(expr)
+		return value, err == nil
+	case *Grouping:
+		return r.constantValue(expr.Expression)
+	}
+	return nil, false
+}
+
 func (r *Resolver) VisitBlockStatement(b *BlockStatement) (any, error) {
 	r.beginScope()
 	defer r.endScope()
@@ -140,13 +269,69 @@ func (r *Resolver) VisitFunction(f *Function) (any, error) {
 	return nil, nil
 }
 
+func (r *Resolver) VisitNamedArgument(n *NamedArgument) (any, error) {
+	r.resolveExpr(n.Value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSpawn(s *Spawn) (any, error) {
+	r.resolveExpr(s.Call)
+	return nil, nil
+}
+
+func (r *Resolver) VisitReceive(rec *Receive) (any, error) {
+	r.resolveExpr(rec.Channel)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSpread(s *Spread) (any, error) {
+	r.resolveExpr(s.Expr)
+	return nil, nil
+}
+
 func (r *Resolver) VisitVarAssignment(v *VarAssignment) (any, error) {
-	r.resolveFinal(v.Token, v)
+	r.resolveAssignment(v)
 	r.resolveExpr(v.Expr)
 
 	return nil, nil
 }
 
+func (r *Resolver) resolveAssignment(v *VarAssignment) {
+	val := r.resolveFinal(v.Token, v)
+	if val != nil && val.Constant {
+		r.Lox.Error(v.Token, "Cannot assign to constant "+v.Token.Lexeme)
+	}
+}
+
+func (r *Resolver) VisitDestructuringDeclaration(d *DestructuringDeclaration) (any, error) {
+	r.resolvePattern(d.Pattern)
+	names := d.Pattern.names()
+	for _, name := range names {
+		r.declare(name)
+	}
+	r.resolveExpr(d.Expr)
+	for _, name := range names {
+		r.define(name)
+		if d.Constant && !r.isEmpty() {
+			cur := r.Scopes[len(r.Scopes)-1]
+			val, _ := r.findByName(*cur, name.Lexeme)
+			val.Constant = true
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitDestructuringAssignment(d *DestructuringAssignment) (any, error) {
+	r.resolvePattern(d.Pattern)
+	r.resolveExpr(d.Expr)
+	for _, assignment := range d.Assignments {
+		r.resolveAssignment(assignment)
+	}
+
+	return nil, nil
+}
+
 func (r *Resolver) VisitGrouping(g *Grouping) (any, error) {
 	r.resolveExpr(g.Expression)
 
@@ -181,6 +366,156 @@ func (r *Resolver) VisitUnary(u *Unary) (any, error) {
 	return nil, nil
 }
 
+func (r *Resolver) VisitInterpolation(in *Interpolation) (any, error) {
+	for _, part := range in.Parts {
+		r.resolveExpr(part)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndex(in *Index) (any, error) {
+	r.resolveExpr(in.Object)
+	r.resolveExpr(in.Index)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitProperty(p *Property) (any, error) {
+	r.resolveExpr(p.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitListLiteral(l *ListLiteral) (any, error) {
+	for _, element := range l.Elements {
+		r.resolveExpr(element)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitTupleLiteral(t *TupleLiteral) (any, error) {
+	for _, element := range t.Elements {
+		r.resolveExpr(element)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitRangeLiteral(rl *RangeLiteral) (any, error) {
+	r.resolveExpr(rl.Start)
+	r.resolveExpr(rl.End)
+	if rl.Step != nil {
+		r.resolveExpr(rl.Step)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapLiteral(m *MapLiteral) (any, error) {
+	for idx, key := range m.Keys {
+		r.resolveExpr(key)
+		r.resolveExpr(m.Values[idx])
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMatch(m *Match) (any, error) {
+	r.resolveExpr(m.Value)
+
+	for _, arm := range m.Arms {
+		// Enums are looked up outside the scope of the arm
+		r.resolvePattern(arm.Pattern)
+		r.beginScope()
+		for _, name := range arm.Pattern.names() {
+			r.declare(name)
+			r.define(name)
+		}
+		if arm.Guard != nil {
+			r.resolveExpr(arm.Guard)
+		}
+		r.resolveExpr(arm.Body)
+		r.endScope()
+	}
+
+	return nil, nil
+}
+
+// Resolves the enums of the pattern, reports names bound twice and
+// alternatives binding different names
+func (r *Resolver) resolvePattern(pattern Pattern) {
+	r.resolveEnums(pattern)
+	seen := map[string]bool{}
+	for _, name := range pattern.names() {
+		if seen[name.Lexeme] {
+			r.Lox.Error(name, "Identifier "+name.Lexeme+" is bound more than once in the pattern")
+		}
+		seen[name.Lexeme] = true
+	}
+	r.checkAlternatives(pattern)
+}
+
+func (r *Resolver) resolveEnums(pattern Pattern) {
+	switch pattern := pattern.(type) {
+	case *EnumPattern:
+		r.resolveExpr(pattern.Enum)
+		for _, value := range pattern.Values {
+			r.resolveEnums(value)
+		}
+	case *AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			r.resolveEnums(alternative)
+		}
+	case *ListPattern:
+		for _, element := range pattern.Elements {
+			r.resolveEnums(element)
+		}
+	case *TuplePattern:
+		for _, element := range pattern.Elements {
+			r.resolveEnums(element)
+		}
+	case *MapPattern:
+		for _, value := range pattern.Values {
+			r.resolveEnums(value)
+		}
+	}
+}
+
+func (r *Resolver) checkAlternatives(pattern Pattern) {
+	switch pattern := pattern.(type) {
+	case *AlternativePattern:
+		expected := pattern.Alternatives[0].names()
+		for _, alternative := range pattern.Alternatives {
+			r.checkAlternatives(alternative)
+
+			names := alternative.names()
+			same := len(names) == len(expected)
+			for idx := 0; same && idx < len(names); idx += 1 {
+				same = names[idx].Lexeme == expected[idx].Lexeme
+			}
+			if !same {
+				r.Lox.Error(pattern.Pipe, "Every alternative of a pattern must bind the same identifiers")
+				return
+			}
+		}
+	case *ListPattern:
+		for _, element := range pattern.Elements {
+			r.checkAlternatives(element)
+		}
+	case *TuplePattern:
+		for _, element := range pattern.Elements {
+			r.checkAlternatives(element)
+		}
+	case *MapPattern:
+		for _, value := range pattern.Values {
+			r.checkAlternatives(value)
+		}
+	case *EnumPattern:
+		for _, value := range pattern.Values {
+			r.checkAlternatives(value)
+		}
+	}
+}
+
 func (r *Resolver) VisitIdentifier(i *IdentifierExpr) (any, error) {
 	if r.isEmpty() {
 		return nil, nil
@@ -203,24 +538,26 @@ func (r *Resolver) findByName(arr []*ScopeValue, name string) (*ScopeValue, int)
 	return nil, -1
 }
 
-func (r *Resolver) resolveFinal(token *Token, expr Expression) {
+// Returns the scope value the token refers to, nil for globals
+func (r *Resolver) resolveFinal(token *Token, expr Expression) *ScopeValue {
 	for idx := len(r.Scopes) - 1; idx >= 0; idx -= 1 {
 		curr := r.Scopes[idx]
 		val, foundIdx := r.findByName(*curr, token.Lexeme)
 		if foundIdx != -1 {
 			if val.Status < DEFINED {
 				r.Lox.Error(token, "Can't read local variable in its own initializer")
-				return
+				return val
 			}
 
 			val.Status = USED
 
 			dist := len(r.Scopes) - 1 - idx
-			r.Interpreter.Locals[expr] = &Local{Distance: dist, Index: foundIdx}
+			r.Interpreter.setLocal(expr, &Local{Distance: dist, Index: foundIdx})
 
-			break
+			return val
 		}
 	}
+	return nil
 }
 
 func (r *Resolver) declare(token *Token) {
@@ -2,7 +2,11 @@ package main
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Scanner struct {
@@ -14,6 +18,17 @@ type Scanner struct {
 	start     int
 	current   int
 	lineCount int
+
+	// Every string interpolation we are in, innermost last
+	interpolations []*interpolation
+}
+
+type interpolation struct {
+	// Unclosed braces inside the interpolated expression
+	braces int
+	// The string the interpolation belongs to
+	triple bool
+	indent int
 }
 
 func CreateScanner(src string, lox *Lox) *Scanner {
@@ -37,13 +52,16 @@ func (s *Scanner) scanTokens() []Token {
 		s.start = s.current
 		s.processChar()
 	}
+	if len(s.interpolations) > 0 {
+		s.Lox.error(Token{Type: EOF, Line: s.lineCount}, "Unterminated string interpolation")
+	}
 	s.Tokens = append(s.Tokens, Token{Type: EOF, Literal: nil, Lexeme: "EOF", Line: s.lineCount})
 	return s.Tokens
 }
 
 func (s *Scanner) advance() rune {
-	cur := rune(s.Source[s.current])
-	s.current += 1
+	cur, size := utf8.DecodeRuneInString(s.Source[s.current:])
+	s.current += size
 	return cur
 }
 
@@ -57,10 +75,27 @@ func (s *Scanner) processChar() {
 	case ')':
 		s.addToken(RIGHT_PAREN)
 		break
+	case '[':
+		s.addToken(LEFT_BRACKET)
+	case ']':
+		s.addToken(RIGHT_BRACKET)
 	case '{':
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1].braces += 1
+		}
 		s.addToken(LEFT_BRACE)
 		break
 	case '}':
+		if len(s.interpolations) > 0 {
+			top := s.interpolations[len(s.interpolations)-1]
+			if top.braces == 0 {
+				// End of the interpolated expression, back inside the string
+				s.interpolations = s.interpolations[:len(s.interpolations)-1]
+				s.string(false, top.triple, top.indent)
+				break
+			}
+			top.braces -= 1
+		}
 		s.addToken(RIGHT_BRACE)
 	case '?':
 		s.addToken(QUESTION_MARK)
@@ -69,6 +104,26 @@ func (s *Scanner) processChar() {
 		s.addToken(COLON)
 		break
 	case '.':
+		if s.isNumber(s.peek()) {
+			s.number()
+			break
+		}
+		if s.peek() == '.' && s.peekNext() == '.' {
+			s.advance()
+			s.advance()
+			s.addToken(ELLIPSIS)
+			break
+		}
+		if s.peek() == '.' {
+			s.advance()
+			if s.peek() == '=' {
+				s.advance()
+				s.addToken(DOT_DOT_EQUAL)
+				break
+			}
+			s.addToken(DOT_DOT)
+			break
+		}
 		s.addToken(DOT)
 		break
 	case '+':
@@ -90,6 +145,7 @@ func (s *Scanner) processChar() {
 			s.addToken(OR)
 			break
 		}
+		s.addToken(PIPE)
 	case '&':
 		if s.match('&') {
 			s.addToken(AND)
@@ -98,6 +154,8 @@ func (s *Scanner) processChar() {
 	case '=':
 		if s.match('=') {
 			s.addToken(EQUAL_EQUAL)
+		} else if s.match('>') {
+			s.addToken(ARROW)
 		} else {
 			s.addToken(EQUAL)
 		}
@@ -119,6 +177,8 @@ func (s *Scanner) processChar() {
 	case '<':
 		if s.match('=') {
 			s.addToken(LESS_EQUAL)
+		} else if s.match('-') {
+			s.addToken(LEFT_ARROW)
 		} else {
 			s.addToken(LESS)
 		}
@@ -137,7 +197,7 @@ func (s *Scanner) processChar() {
 		}
 		break
 	case '"':
-		s.string()
+		s.openString(false)
 		break
 	case 39:
 		s.char()
@@ -150,6 +210,11 @@ func (s *Scanner) processChar() {
 	case '\t':
 		break
 	default:
+		if ch == 'r' && s.peek() == '"' {
+			s.advance()
+			s.openString(true)
+			break
+		}
 		if s.isNumber(ch) {
 			s.number()
 			break
@@ -159,7 +224,7 @@ func (s *Scanner) processChar() {
 			break
 		}
 		// TODO : handle unknown input
-		s.Lox.error(Token{Type: EQUAL, Line: s.lineCount, Lexeme: string(ch)}, "Invalid token")
+		s.Lox.error(Token{Type: EQUAL, Line: s.lineCount, Column: s.columnAt(s.start), Lexeme: string(ch)}, "Invalid token")
 		break
 	}
 }
@@ -168,51 +233,221 @@ func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return rune(0)
 	}
-	return rune(s.Source[s.current])
+	cur, _ := utf8.DecodeRuneInString(s.Source[s.current:])
+	return cur
 }
 
 func (s *Scanner) peekNext() rune {
-	if s.current+1 >= len(s.Source) {
+	if s.isAtEnd() {
+		return rune(0)
+	}
+	_, size := utf8.DecodeRuneInString(s.Source[s.current:])
+	if s.current+size >= len(s.Source) {
 		return rune(0)
 	}
-	return rune(s.Source[s.current+1])
+	next, _ := utf8.DecodeRuneInString(s.Source[s.current+size:])
+	return next
 }
 
 func (s *Scanner) match(char rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if rune(s.Source[s.current]) != char {
+	if s.peek() != char {
 		return false
 	}
-	s.current += 1
+	s.advance()
 	return true
 }
 
-func (s *Scanner) string() {
-	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
+// Column, counted in characters, of the given position in the source
+func (s *Scanner) columnAt(position int) int {
+	lineStart := strings.LastIndexByte(s.Source[:position], '\n') + 1
+	return utf8.RuneCountInString(s.Source[lineStart:position]) + 1
+}
+
+// The opening quote has already been consumed.
+//
+// "..." : escapes and interpolation
+// r"..." : raw, no escapes nor interpolation
+// """...""" : multi-line, the common indentation of the lines is stripped as
+// well as the line breaks right after the opening and before the closing quotes
+func (s *Scanner) openString(raw bool) {
+	if s.peek() != '"' || s.peekNext() != '"' {
+		s.string(raw, false, 0)
+		return
+	}
+
+	s.advance()
+	s.advance()
+	indent := s.tripleStringIndent()
+	if s.peek() == '\n' {
+		s.advance()
+		s.lineCount += 1
+	}
+	s.skipIndent(indent)
+	s.string(raw, true, indent)
+}
+
+// "Hello ${name} !" is scanned as INTERPOLATION("Hello "), IDENTIFIER(name), STRING(" !")
+func (s *Scanner) string(raw bool, triple bool, indent int) {
+	var str strings.Builder
+	// Position in str of the last line break, to drop a trailing blank line
+	lastLineBreak, blankLine := -1, false
+
+	for !s.isAtEnd() && !s.isStringEnd(triple) {
+		if !raw && s.peek() == '$' && s.peekNext() == '{' {
+			s.advance()
+			s.advance()
+			s.addTokenLiteral(INTERPOLATION, str.String())
+			s.interpolations = append(s.interpolations, &interpolation{triple: triple, indent: indent})
+			return
+		}
+		if !raw && s.peek() == '\\' {
+			s.escape(&str)
+			blankLine = false
+			continue
+		}
+
+		ch := s.advance()
+		if ch == '\n' {
 			s.lineCount += 1
+			lastLineBreak, blankLine = str.Len(), true
+			str.WriteRune(ch)
+			if triple {
+				s.skipIndent(indent)
+			}
+			continue
 		}
-		s.advance()
+		if ch != ' ' && ch != '\t' && ch != '\r' {
+			blankLine = false
+		}
+		str.WriteRune(ch)
 	}
-	if s.peek() != '"' {
-		s.Lox.error(Token{Type: STRING, Line: s.lineCount, Lexeme: string(s.peek())}, "Invalid string")
+
+	if s.isAtEnd() {
+		s.Lox.error(Token{Type: STRING, Line: s.lineCount, Column: s.columnAt(s.start), Lexeme: s.Source[s.start:s.current]}, "Unterminated string")
 		return
 	}
 	s.advance()
-	s.addTokenLiteral(STRING, s.Source[s.start+1:s.current-1])
+	text := str.String()
+	if triple {
+		s.advance()
+		s.advance()
+		if blankLine {
+			text = text[:lastLineBreak]
+		}
+	}
+	s.addTokenLiteral(STRING, text)
+}
+
+func (s *Scanner) isStringEnd(triple bool) bool {
+	if !triple {
+		return s.peek() == '"'
+	}
+	return strings.HasPrefix(s.Source[s.current:], `"""`)
+}
+
+// Smallest indentation among the lines of the triple quoted string starting
+// at the current position, blank lines aside. The line of the closing quotes
+// is always taken into account.
+func (s *Scanner) tripleStringIndent() int {
+	body, _, _ := strings.Cut(s.Source[s.current:], `"""`)
+	lines := strings.Split(body, "\n")
+
+	indent := -1
+	for idx, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		isLast := idx == len(lines)-2
+		if trimmed == "" && !isLast {
+			continue
+		}
+		if width := len(line) - len(trimmed); indent == -1 || width < indent {
+			indent = width
+		}
+	}
+	return max(indent, 0)
+}
+
+func (s *Scanner) skipIndent(indent int) {
+	for n := 0; n < indent && (s.peek() == ' ' || s.peek() == '\t'); n += 1 {
+		s.advance()
+	}
+}
+
+// \n \t \r \0 \\ \" \' \$ \u{1F600}
+func (s *Scanner) escape(str *strings.Builder) {
+	escapeStart := s.current
+	s.advance()
+
+	switch ch := s.peek(); ch {
+	case 'n':
+		s.advance()
+		str.WriteByte('\n')
+		return
+	case 't':
+		s.advance()
+		str.WriteByte('\t')
+		return
+	case 'r':
+		s.advance()
+		str.WriteByte('\r')
+		return
+	case '0':
+		s.advance()
+		str.WriteByte(0)
+		return
+	case '\\', '"', '\'', '$':
+		s.advance()
+		str.WriteRune(ch)
+		return
+	case 'u':
+		s.advance()
+		if !s.match('{') {
+			break
+		}
+		digitsStart := s.current
+		for s.isHexDigit(s.peek()) {
+			s.advance()
+		}
+		digits := s.Source[digitsStart:s.current]
+		if !s.match('}') || len(digits) == 0 || len(digits) > 6 {
+			break
+		}
+		code, _ := strconv.ParseInt(digits, 16, 32)
+		if !utf8.ValidRune(rune(code)) {
+			break
+		}
+		str.WriteRune(rune(code))
+		return
+	default:
+		if !s.isAtEnd() && s.peek() != '\n' {
+			s.advance()
+		}
+	}
+
+	s.Lox.error(Token{Type: STRING, Line: s.lineCount, Column: s.columnAt(escapeStart), Lexeme: s.Source[escapeStart:s.current]}, "Invalid escape sequence")
+}
+
+func (s *Scanner) isHexDigit(char rune) bool {
+	return s.isNumber(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
 }
 
 func (s *Scanner) char() {
-	ch := s.advance()
-	if s.peek() != 39 {
-		s.CreateCompileError(Token{Type: CHAR, Line: s.lineCount, Lexeme: string(s.peek())}, "Invalid char")
+	var str strings.Builder
+	if s.peek() == '\\' {
+		s.escape(&str)
+	} else {
+		str.WriteRune(s.advance())
+	}
+	if s.peek() != 39 || str.Len() == 0 {
+		s.CreateCompileError(Token{Type: CHAR, Line: s.lineCount, Column: s.columnAt(s.current), Lexeme: string(s.peek())}, "Invalid char")
 		s.current = len(s.Source)
 		return
 	}
 	s.advance()
-	s.addTokenLiteral(CHAR, float64(ch))
+	ch, _ := utf8.DecodeRuneInString(str.String())
+	s.addTokenLiteral(CHAR, int64(ch))
 }
 
 func (s *Scanner) multilineComment() {
@@ -236,7 +471,8 @@ func (s *Scanner) isNumber(char rune) bool {
 func (s *Scanner) isAlpha(char rune) bool {
 	return (char >= 'a' && char <= 'z') ||
 		(char >= 'A' && char <= 'Z') ||
-		(char == '_')
+		(char == '_') ||
+		(char >= utf8.RuneSelf && unicode.IsLetter(char))
 }
 func (s *Scanner) isAlphaNumeric(char rune) bool {
 	return s.isAlpha(char) || s.isNumber(char)
@@ -246,23 +482,170 @@ func (s *Scanner) isAlphaNumeric(char rune) bool {
 func (s *Scanner) isValidDigit() bool {
 	return s.isNumber(s.peek())
 }
+
+// 12, 1_000, 1.5, .5, 1.5e-3, 0xFF, 0o17, 0b1010, 12n (bigint), 1.50d (decimal)
 func (s *Scanner) number() {
-	for s.isNumber(s.peek()) {
+	if s.Source[s.start] == '0' && strings.ContainsRune("xXoObB", s.peek()) {
+		s.prefixedNumber()
+		return
+	}
+
+	// Leading dot : the fractional part is all we have
+	isFloat := s.Source[s.start] == '.'
+	_, valid := s.digits(10, true)
+	if !isFloat && s.peek() == '.' && s.isNumber(s.peekNext()) {
+		isFloat = true
 		s.advance()
+		_, validFraction := s.digits(10, false)
+		valid = valid && validFraction
 	}
-	if s.peek() == '.' && s.isNumber(s.peekNext()) {
+	hasExponent := false
+	if s.peek() == 'e' || s.peek() == 'E' {
+		hasExponent = true
 		s.advance()
-		for s.isNumber(s.peek()) {
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		count, validExponent := s.digits(10, false)
+		if count == 0 {
+			s.numberError("Expected digits in the exponent")
+			return
+		}
+		valid = valid && validExponent
+	}
+	if !valid {
+		s.numberError("Digit separator '_' must be between digits")
+		return
+	}
+	text := strings.ReplaceAll(s.Source[s.start:s.current], "_", "")
+
+	if (s.peek() == 'n' || s.peek() == 'd') && !s.isAlphaNumeric(s.peekNext()) {
+		suffix := s.advance()
+		if suffix == 'd' {
+			parsed, ok := parseDecimal(text)
+			if !ok {
+				s.numberError("Decimal literal out of range")
+				return
+			}
+			s.addTokenLiteral(NUMBER, parsed)
+			return
+		}
+		if isFloat || hasExponent {
+			s.numberError("Bigint literal cannot have a fractional part or an exponent")
+			return
+		}
+		parsed, _ := new(big.Int).SetString(text, 10)
+		s.addTokenLiteral(NUMBER, parsed)
+		return
+	}
+	if s.isAlphaNumeric(s.peek()) {
+		s.numberError("Invalid character in number literal")
+		return
+	}
+
+	if isFloat || hasExponent {
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			s.numberError("Number literal out of range")
+			return
+		}
+		s.addTokenLiteral(NUMBER, parsed)
+		return
+	}
+	s.addIntegerLiteral(text, 10)
+}
+
+// 0xFF, 0o17, 0b1010, optionally with a `n` suffix
+func (s *Scanner) prefixedNumber() {
+	var base int
+	var name string
+	switch s.advance() {
+	case 'x', 'X':
+		base, name = 16, "hexadecimal"
+	case 'o', 'O':
+		base, name = 8, "octal"
+	case 'b', 'B':
+		base, name = 2, "binary"
+	}
+
+	count, valid := s.digits(base, false)
+	if count == 0 {
+		s.numberError("Expected " + name + " digits after '" + s.Source[s.start:s.current] + "'")
+		return
+	}
+	if !valid {
+		s.numberError("Digit separator '_' must be between digits")
+		return
+	}
+	text := strings.ReplaceAll(s.Source[s.start+2:s.current], "_", "")
+
+	if s.peek() == 'n' && !s.isAlphaNumeric(s.peekNext()) {
+		s.advance()
+		parsed, _ := new(big.Int).SetString(text, base)
+		s.addTokenLiteral(NUMBER, parsed)
+		return
+	}
+	if s.isAlphaNumeric(s.peek()) {
+		s.numberError("Invalid digit in " + name + " literal")
+		return
 	}
-	parsed, err := strconv.ParseFloat(s.Source[s.start:s.current], 64)
+	s.addIntegerLiteral(text, base)
+}
+
+func (s *Scanner) addIntegerLiteral(text string, base int) {
+	parsed, err := strconv.ParseInt(text, base, 64)
 	if err != nil {
-		panic("UNREACHABLE")
+		// Too big for int64
+		bigParsed, _ := new(big.Int).SetString(text, base)
+		s.addTokenLiteral(NUMBER, bigParsed)
+		return
 	}
 	s.addTokenLiteral(NUMBER, parsed)
 }
 
+// Consumes the digits of the given base along with their `_` separators.
+// Returns how many digits were read and whether every separator sits
+// between two digits.
+func (s *Scanner) digits(base int, afterDigit bool) (int, bool) {
+	count, valid := 0, true
+	for {
+		if s.peek() == '_' {
+			if !afterDigit || !s.isDigitOf(s.peekNext(), base) {
+				valid = false
+			}
+			s.advance()
+			afterDigit = false
+			continue
+		}
+		if !s.isDigitOf(s.peek(), base) {
+			return count, valid
+		}
+		s.advance()
+		count += 1
+		afterDigit = true
+	}
+}
+
+func (s *Scanner) isDigitOf(char rune, base int) bool {
+	switch base {
+	case 2:
+		return char == '0' || char == '1'
+	case 8:
+		return char >= '0' && char <= '7'
+	case 16:
+		return s.isHexDigit(char)
+	}
+	return s.isNumber(char)
+}
+
+// Reports a malformed literal, skipping the rest of it
+func (s *Scanner) numberError(msg string) {
+	for s.isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	s.Lox.error(Token{Type: NUMBER, Line: s.lineCount, Column: s.columnAt(s.start), Lexeme: s.Source[s.start:s.current]}, msg)
+}
+
 func (s *Scanner) alpha() {
 	for s.isAlphaNumeric(s.peek()) {
 		s.advance()
@@ -290,12 +673,12 @@ func (s *Scanner) alpha() {
 // x=1+1\n
 func (s *Scanner) addToken(tokenType TokenType) {
 	text := s.Source[s.start:s.current]
-	s.Tokens = append(s.Tokens, Token{Type: tokenType, Literal: nil, Lexeme: text, Line: s.lineCount})
+	s.Tokens = append(s.Tokens, Token{Type: tokenType, Literal: nil, Lexeme: text, Line: s.lineCount, Column: s.columnAt(s.start)})
 }
 
 func (s *Scanner) addTokenLiteral(tokenType TokenType, literal interface{}) {
 	text := s.Source[s.start:s.current]
-	s.Tokens = append(s.Tokens, Token{Type: tokenType, Literal: literal, Lexeme: text, Line: s.lineCount})
+	s.Tokens = append(s.Tokens, Token{Type: tokenType, Literal: literal, Lexeme: text, Line: s.lineCount, Column: s.columnAt(s.start)})
 }
 
 func (s *Scanner) CreateCompileError(token Token, msg string) {
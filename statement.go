@@ -16,8 +16,15 @@ type StatementVisitor interface {
 	VisitBlockStatement(v *BlockStatement) (any, error)
 	VisitIfStatement(i *IfStatement) (any, error)
 	VisitWhileStatement(w *WhileStatement) (any, error)
+	VisitForInStatement(f *ForInStatement) (any, error)
 	VisitFunctionDeclaration(f *FunctionDeclaration) (any, error)
 	VisitReturnStatement(r *ReturnStatement) (any, error)
+	VisitYieldStatement(y *YieldStatement) (any, error)
+	VisitSwitchStatement(s *SwitchStatement) (any, error)
+	VisitSendStatement(s *SendStatement) (any, error)
+	VisitSelectStatement(s *SelectStatement) (any, error)
+	VisitDestructuringDeclaration(d *DestructuringDeclaration) (any, error)
+	VisitEnumDeclaration(e *EnumDeclaration) (any, error)
 }
 
 type ExpressionStatement struct {
@@ -51,6 +58,16 @@ func CreatePrintStatement(expr Expression) *PrintStatement {
 type VarDeclaration struct {
 	Identifier *Token
 	Expr       Expression
+	// Declared with `const`, can't be reassigned
+	Constant bool
+	// nil when not annotated
+	Type *TypeAnnotation
+}
+
+// `number` or `number?` : only used by the type checker
+type TypeAnnotation struct {
+	Name     *Token
+	Nullable bool
 }
 
 func (v *VarDeclaration) accept(visitor StatementVisitor) (any, error) {
@@ -64,20 +81,83 @@ func CreateVarDeclaration(expr Expression, identifier *Token) *VarDeclaration {
 	}
 }
 
+func CreateConstDeclaration(expr Expression, identifier *Token) *VarDeclaration {
+	return &VarDeclaration{
+		Expr:       expr,
+		Identifier: identifier,
+		Constant:   true,
+	}
+}
+
+// let [a, b, ...rest] = list; const {name, age: years} = map;
+type DestructuringDeclaration struct {
+	Keyword  *Token
+	Pattern  Pattern
+	Expr     Expression
+	Constant bool
+}
+
+func (d *DestructuringDeclaration) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitDestructuringDeclaration(d)
+}
+
+func CreateDestructuringDeclaration(keyword *Token, pattern Pattern, expr Expression) *DestructuringDeclaration {
+	return &DestructuringDeclaration{
+		Keyword:  keyword,
+		Pattern:  pattern,
+		Expr:     expr,
+		Constant: keyword.Type == CONST,
+	}
+}
+
+// enum Option { Some(value), None }
+type EnumDeclaration struct {
+	Identifier *Token
+	Variants   []*VariantDeclaration
+}
+
+type VariantDeclaration struct {
+	Name *Token
+	// Names of the associated values, nil for a plain variant
+	Params []*Token
+}
+
+func (e *EnumDeclaration) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitEnumDeclaration(e)
+}
+
+func CreateEnumDeclaration(identifier *Token, variants []*VariantDeclaration) *EnumDeclaration {
+	return &EnumDeclaration{
+		Identifier: identifier,
+		Variants:   variants,
+	}
+}
+
 type FunctionDeclaration struct {
 	Identifier *Token
 	Params     []*Token
-	Stmts      []Statement
+	// Default value of each param, nil when the param is required
+	Defaults []Expression
+	// ...rest, nil when the function isn't variadic
+	Rest  *Token
+	Stmts []Statement
+	// Annotation of each param and of the result, nil when not annotated
+	ParamTypes []*TypeAnnotation
+	ReturnType *TypeAnnotation
+	// Set by the resolver when the body contains `yield`
+	Generator bool
 }
 
 func (f *FunctionDeclaration) accept(visitor StatementVisitor) (any, error) {
 	return visitor.VisitFunctionDeclaration(f)
 }
 
-func CreateFunctionDeclaration(identifier *Token, params []*Token, stmts []Statement) *FunctionDeclaration {
+func CreateFunctionDeclaration(identifier *Token, params []*Token, defaults []Expression, rest *Token, stmts []Statement) *FunctionDeclaration {
 	return &FunctionDeclaration{
 		Identifier: identifier,
 		Params:     params,
+		Defaults:   defaults,
+		Rest:       rest,
 		Stmts:      stmts,
 	}
 }
@@ -98,6 +178,32 @@ func CreateVarAssignment(token *Token, expr Expression) *VarAssignment {
 	}
 }
 
+// [a, b] = [b, a]
+type DestructuringAssignment struct {
+	Token   *Token
+	Pattern Pattern
+	// One per name bound by the pattern, without value
+	Assignments []*VarAssignment
+	Expr        Expression
+}
+
+func (d *DestructuringAssignment) accept(visitor ExpressionVisitor) (any, error) {
+	return visitor.VisitDestructuringAssignment(d)
+}
+
+func CreateDestructuringAssignment(token *Token, pattern Pattern, expr Expression) *DestructuringAssignment {
+	assignments := []*VarAssignment{}
+	for _, name := range pattern.names() {
+		assignments = append(assignments, CreateVarAssignment(name, nil))
+	}
+	return &DestructuringAssignment{
+		Token:       token,
+		Pattern:     pattern,
+		Assignments: assignments,
+		Expr:        expr,
+	}
+}
+
 type BlockStatement struct {
 	Statements []Statement
 }
@@ -133,6 +239,10 @@ func CreateIfStatement(expr Expression, ifStmt Statement, elseStmt Statement) *I
 type WhileStatement struct {
 	Expr Expression
 	Stmt Statement
+	// do { } while (cond); runs Stmt once before checking Expr
+	DoWhile bool
+	// Runs when the loop ends without break, may be nil
+	Else Statement
 }
 
 func (w *WhileStatement) accept(visitor StatementVisitor) (any, error) {
@@ -146,6 +256,106 @@ func CreateWhileStatement(expr Expression, stmt Statement) *WhileStatement {
 	}
 }
 
+// for (x in iterable) { }, for (k, v in map) { }
+type ForInStatement struct {
+	Keyword *Token
+	// One or two loop variables, bound afresh at each iteration
+	Names    []*Token
+	Iterable Expression
+	Stmt     Statement
+	// Runs when the loop ends without break, may be nil
+	Else Statement
+}
+
+func (f *ForInStatement) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitForInStatement(f)
+}
+
+func CreateForInStatement(keyword *Token, names []*Token, iterable Expression, stmt Statement) *ForInStatement {
+	return &ForInStatement{
+		Keyword:  keyword,
+		Names:    names,
+		Iterable: iterable,
+		Stmt:     stmt,
+	}
+}
+
+// switch (value) { case 1: ... case 2, 3: ... fallthrough; default: ... }
+type SwitchStatement struct {
+	Keyword *Token
+	Value   Expression
+	Cases   []*SwitchCase
+}
+
+type SwitchCase struct {
+	// `case` or `default` keyword
+	Token *Token
+	// nil for the default case
+	Labels      []Expression
+	Body        *BlockStatement
+	Fallthrough bool
+}
+
+func (s *SwitchStatement) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitSwitchStatement(s)
+}
+
+func CreateSwitchStatement(keyword *Token, value Expression, cases []*SwitchCase) *SwitchStatement {
+	return &SwitchStatement{
+		Keyword: keyword,
+		Value:   value,
+		Cases:   cases,
+	}
+}
+
+// channel <- value;
+type SendStatement struct {
+	Channel Expression
+	Arrow   *Token
+	Value   Expression
+}
+
+func (s *SendStatement) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitSendStatement(s)
+}
+
+func CreateSendStatement(channel Expression, arrow *Token, value Expression) *SendStatement {
+	return &SendStatement{
+		Channel: channel,
+		Arrow:   arrow,
+		Value:   value,
+	}
+}
+
+// select { case let v = <-a: ... case b <- 1: ... default: ... }
+type SelectStatement struct {
+	Keyword *Token
+	Cases   []*SelectCase
+}
+
+type SelectCase struct {
+	// `case` or `default` keyword
+	Token *Token
+	// nil for the default case
+	Channel Expression
+	// Value sent, nil when receiving
+	Value Expression
+	// Bound to the received value with `let`, may be nil
+	Name *Token
+	Body *BlockStatement
+}
+
+func (s *SelectStatement) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitSelectStatement(s)
+}
+
+func CreateSelectStatement(keyword *Token, cases []*SelectCase) *SelectStatement {
+	return &SelectStatement{
+		Keyword: keyword,
+		Cases:   cases,
+	}
+}
+
 type BreakStatement struct{}
 
 var BreakStmtErr = errors.New("BreakStatement")
@@ -158,6 +368,22 @@ func CreateBreakStatement() *BreakStatement {
 	return &BreakStatement{}
 }
 
+type YieldStatement struct {
+	Keyword *Token
+	Expr    Expression
+}
+
+func (y *YieldStatement) accept(visitor StatementVisitor) (any, error) {
+	return visitor.VisitYieldStatement(y)
+}
+
+func CreateYieldStatement(keyword *Token, expr Expression) *YieldStatement {
+	return &YieldStatement{
+		Keyword: keyword,
+		Expr:    expr,
+	}
+}
+
 type ReturnStatement struct {
 	Expr Expression
 	*Token
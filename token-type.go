@@ -8,6 +8,8 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
@@ -17,6 +19,7 @@ const (
 	STAR
     COLON
     QUESTION_MARK
+	PIPE
 
 	// One or two character token
 	BANG
@@ -27,12 +30,19 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	ARROW
+	ELLIPSIS
+	DOT_DOT
+	DOT_DOT_EQUAL
+	LEFT_ARROW
 
 	// Literal
 	IDENTIFIER
 	STRING
 	NUMBER
     CHAR
+	// String segment followed by an interpolated expression "...${"
+	INTERPOLATION
 
 	// Keywords
 	AND
@@ -50,8 +60,20 @@ const (
 	THIS
 	TRUE
 	LET
+	CONST
 	WHILE
 	BREAK
+	MATCH
+	SWITCH
+	CASE
+	DEFAULT
+	FALLTHROUGH
+	IN
+	YIELD
+	SPAWN
+	SELECT
+	ENUM
+	DO
 	EOF
 )
 
@@ -7,6 +7,8 @@ type Token struct {
 	Lexeme  string
 	Literal interface{}
     Line    int
+	// Counted in characters, starting at 1. 0 when unknown
+	Column int
 }
 
 func CreateToken(tokenType TokenType, lexeme string, literal interface{}, line int) *Token {
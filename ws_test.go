@@ -3,7 +3,12 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Do(testCase string, expect string) error {
@@ -50,3 +55,651 @@ func TestExpression(t *testing.T) {
 		}
 	}
 }
+
+// Eval runs the whole pipeline and returns the value of the last statement
+func Eval(source string) (any, error) {
+	lox := Lox{
+		Interpreter: CreateAndSetupInterpreter(),
+	}
+	tokens := CreateScanner(source, &lox).scanTokens()
+	if lox.HadError {
+		return nil, errors.New("Compile error")
+	}
+	statements, _ := CreateParser(tokens, &lox).parse()
+	if lox.HadError {
+		return nil, errors.New("Compile error")
+	}
+	CreateResolver(lox.Interpreter, &lox).resolve(statements)
+	if lox.HadError {
+		return nil, errors.New("Compile error")
+	}
+
+	var result any
+	for _, stmt := range statements {
+		val, err := stmt.accept(lox.Interpreter)
+		if err != nil {
+			return nil, err
+		}
+		result = val
+	}
+	return result, nil
+}
+
+func expectValues(t *testing.T, cases [][2]string) {
+	t.Helper()
+	for i, c := range cases {
+		val, err := Eval(c[0])
+		if err != nil {
+			t.Errorf("Wrong on %d : %s\n", i, err.Error())
+			continue
+		}
+		if got := stringify(val); got != c[1] {
+			t.Errorf("Wrong on %d : expected %s but got %s\n", i, c[1], got)
+		}
+	}
+}
+
+func expectErrors(t *testing.T, cases []string) {
+	t.Helper()
+	for i, c := range cases {
+		if _, err := Eval(c); err == nil {
+			t.Errorf("Wrong on %d : expected an error\n", i)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"7 / 2;", "3"},
+		{"7 / 2.0;", "3.5"},
+		{"1 == 1.0;", "true"},
+		{"9007199254740993;", "9007199254740993"},
+		{"int(3.9) + int(\"2\");", "5"},
+		{"float(3) / 2;", "1.5"},
+		{"\"n = \" + 42;", "n = 42"},
+		{"0 ? \"truthy\" : \"falsy\";", "falsy"},
+	})
+	expectErrors(t, []string{
+		"1 / 0;",
+		"int(\"abc\");",
+	})
+}
+
+func TestArbitraryPrecision(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"9223372036854775807 + 1;", "9223372036854775808"},
+		{"2n * 3;", "6"},
+		{"123456789012345678901234567890 > 1;", "true"},
+		{"0.1d + 0.2d == 0.3d;", "true"},
+		{"1.50d * 2;", "3.00"},
+		{"1d / 8d;", "0.125"},
+		{"2d / 3d;", "0.6666666666666666666666666667"},
+		{"decimal(0.1) + decimal(\"2.35\");", "2.45"},
+		{"int(10n / 3);", "3"},
+	})
+	expectErrors(t, []string{
+		"1.5d + 1.0;",
+		"1n / 0;",
+		"int(9223372036854775807 + 1);",
+	})
+}
+
+func TestInterpolation(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"let name = \"wes\"; \"Hello ${name}!\";", "Hello wes!"},
+		{"let count = 2; \"${count + 1} messages\";", "3 messages"},
+		{"\"outer ${\"inner ${1 + 1}\"}\";", "outer inner 2"},
+		{"\"${nil} ${1.50d} ${true}\";", "nil 1.50 true"},
+		{"\"costs $5\";", "costs $5"},
+	})
+	expectErrors(t, []string{
+		"\"${1 + \";",
+	})
+}
+
+func TestStringLiterals(t *testing.T) {
+	expectValues(t, [][2]string{
+		{`"a\tb\\c \"q\"";`, "a\tb\\c \"q\""},
+		{`"\${x} \u{1F600}";`, "${x} 😀"},
+		{`r"C:\new\${x}";`, `C:\new\${x}`},
+		{"\"\"\"\n    Hello,\n      world\n    \"\"\";", "Hello,\n  world"},
+		{"let n = 1; \"\"\"\n  n = ${n}\n  \"\"\";", "n = 1"},
+		{`'\n';`, "10"},
+	})
+	expectErrors(t, []string{
+		`"\q";`,
+		`"\u{110000}";`,
+		`"unterminated;`,
+	})
+}
+
+func TestUnicode(t *testing.T) {
+	expectValues(t, [][2]string{
+		{`len("héllo 😀");`, "7"},
+		{`"héllo 😀"[1];`, "é"},
+		{`"héllo 😀"[6];`, "😀"},
+		{`let café = "ok"; café;`, "ok"},
+		{`'é';`, "233"},
+	})
+	expectErrors(t, []string{
+		`"é"[1];`,
+		`"abc"[-1];`,
+		`"abc"["a"];`,
+	})
+
+	lox := Lox{}
+	tokens := CreateScanner("// ü\nlet é = \"😀\";", &lox).scanTokens()
+	if tokens[3].Lexeme != "\"😀\"" || tokens[3].Line != 2 || tokens[3].Column != 9 {
+		t.Errorf("Wrong string token : %+v\n", tokens[3])
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"0xFF + 0o17 + 0b1010;", "280"},
+		{"1_000_000;", "1000000"},
+		{"1.5e-3;", "0.0015"},
+		{".5 + 2E3;", "2000.5"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FF;", "4722366482869645213695"},
+		{"0xFFn;", "255"},
+		{"12e-1d;", "1.2"},
+	})
+	expectErrors(t, []string{
+		"0x;",
+		"0b102;",
+		"1__0;",
+		"1_;",
+		"1e;",
+		"1e400;",
+		"12abc;",
+		"1e3n;",
+	})
+}
+
+func TestMatch(t *testing.T) {
+	describe := `fun describe(v) {
+		return match (v) {
+			1 => "one",
+			-1 => "minus one",
+			"a" | "b" => "a or b",
+			[x, y] => "pair ${x} ${y}",
+			{name: n, age: a} if a >= 18 => "adult ${n}",
+			{name: n} => "someone ${n}",
+			n if n > 100 => "big ${n}",
+			_ => "other",
+		};
+	}
+	`
+	expectValues(t, [][2]string{
+		{describe + "describe(1);", "one"},
+		{describe + "describe(-1);", "minus one"},
+		{describe + "describe(\"b\");", "a or b"},
+		{describe + "describe([1, 2]);", "pair 1 2"},
+		{describe + "describe({name: \"wes\", age: 20});", "adult wes"},
+		{describe + "describe({\"name\": \"kid\", age: 3});", "someone kid"},
+		{describe + "describe(500);", "big 500"},
+		{describe + "describe(50);", "other"},
+		{"match ([0, 7]) { [0, x] | [x, 0] => x };", "7"},
+		{"[1, \"a\", {b: [2]}];", "[1, \"a\", {\"b\": [2]}]"},
+		{"[1, [2]] == [1, [2]];", "true"},
+		{"let m = {a: 1, 1.0: 2}; m[1];", "2"},
+	})
+	expectErrors(t, []string{
+		"match (2) { 1 => 1 };",
+		"match (1) { [x, x] => x };",
+		"match (1) { x | [y] => 1 };",
+	})
+}
+
+func TestSwitch(t *testing.T) {
+	name := `fun name(x) {
+		let r = "";
+		switch (x) {
+			case 1:
+				r = "one";
+			case 2, 3:
+				r = "two or three";
+				fallthrough;
+			case 4:
+				r = r + " then four";
+			default:
+				r = "other";
+			case 5:
+				r = "five";
+				break;
+				r = "unreachable";
+		}
+		return r;
+	}
+	`
+	expectValues(t, [][2]string{
+		{name + "name(1);", "one"},
+		{name + "name(3);", "two or three then four"},
+		{name + "name(4);", " then four"},
+		{name + "name(5);", "five"},
+		{name + "name(9);", "other"},
+		{"let n = 0; let i = 0; while (i < 3) { switch (i) { case 1: break; default: n = n + 1; } i = i + 1; } n;", "2"},
+	})
+	expectErrors(t, []string{
+		"switch (1) { case 1: fallthrough; }",
+		"switch (1) { case 1: fallthrough; let x = 1; case 2: }",
+		"switch (1) { default: default: }",
+	})
+}
+
+func TestConst(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"const PI = 3.14; PI;", "3.14"},
+		{"fun f() { const X = 1; return X + 1; } f();", "2"},
+		{"let x = 1; x = 2; x;", "2"},
+	})
+	expectErrors(t, []string{
+		"const PI = 3.14; PI = 3;",
+		"fun f() { const X = 1; X = 2; }",
+		"{ const Z = 1; { Z = 3; } }",
+		"const Y;",
+	})
+}
+
+func TestDestructuring(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"let [a, b, ...rest] = [1, 2, 3, 4]; rest;", "[3, 4]"},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c;", "6"},
+		{"let {name, age: years} = {name: \"Bob\", age: 3}; name + years;", "Bob3"},
+		{"let a = 1; let b = 2; [a, b] = [b, a]; a * 10 + b;", "21"},
+		{"fun f() { let [x, ...r] = [1]; return r; } f();", "[]"},
+	})
+	expectErrors(t, []string{
+		"let [a, b] = [1];",
+		"let [a, b, ...c] = [1];",
+		"let {x} = {y: 1};",
+		"let [a] = 1;",
+		"let [a, ...r, b] = [1, 2];",
+		"const [a] = [1]; a = 2;",
+		"let [a];",
+	})
+}
+
+func TestDefaultAndNamedArgs(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"fun f(a, b = 10) { return a + b; } f(1);", "11"},
+		{"fun f(a, b = a * 2) { return b; } f(4);", "8"},
+		{"fun f(a, b = 1, c = 2) { return [a, b, c]; } f(0, c: 5);", "[0, 1, 5]"},
+		{"fun f(a, b) { return a - b; } f(b: 1, a: 3);", "2"},
+		{"let n = 0; fun f(x = n) { return x; } n = 5; f();", "5"},
+	})
+	expectErrors(t, []string{
+		"fun f(a, b = 1) { return a; } f(1, 2, 3);",
+		"fun f(a, b = 1) { return a; } f(b: 2);",
+		"fun f(a) { return a; } f(1, z: 2);",
+		"fun f(a) { return a; } f(1, a: 2);",
+		"fun f(a = 1, b) { return b; }",
+		"fun f(a) { return a; } f(a: 1, 2);",
+	})
+}
+
+func TestVariadicAndSpread(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"fun f(a, ...rest) { return rest; } f(1);", "[]"},
+		{"fun f(a, ...rest) { return rest; } f(1, 2, 3);", "[2, 3]"},
+		{"fun f(a, b, c) { return a + b + c; } let xs = [2, 3]; f(1, ...xs);", "6"},
+		{"let xs = [1, 2]; [0, ...xs, ...[], 3];", "[0, 1, 2, 3]"},
+		{"let a = 0; let b = nil; [a, ...b] = [1, 2, 3]; b;", "[2, 3]"},
+	})
+	expectErrors(t, []string{
+		"fun f(a, ...rest) { return a; } f();",
+		"fun f(...rest, a) { return a; }",
+		"fun f(a) { return a; } f(...1);",
+		"fun f(...r) { return r; } let xs = []; let i = 0; while (i < 256) { xs = [...xs, i]; i = i + 1; } f(...xs);",
+	})
+}
+
+func TestForIn(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"let s = 0; for (x in [1, 2, 3]) { s = s + x; } s;", "6"},
+		{"let s = \"\"; for (c in \"héllo\") { s = c + s; } s;", "olléh"},
+		{"let s = \"\"; for (k, v in {a: 1, b: 2}) { s = s + k + v; } s;", "a1b2"},
+		{"let s = \"\"; for (k in {a: 1, b: 2}) { s = s + k; } s;", "ab"},
+		{"let fs = []; for (x in [1, 2]) { fun f() { return x; } fs = [...fs, f]; } fs[0]() + fs[1]() * 10;", "21"},
+		{"let n = 0; fun next() { n = n + 1; if (n > 3) { return nil; } return n; } let s = 0; for (x in {next: next}) { s = s + x; } s;", "6"},
+		{"fun iter() { return [1, 2, 3]; } let s = 0; for (x in {iter: iter}) { if (x == 3) { break; } s = s + x; } s;", "3"},
+	})
+	expectErrors(t, []string{
+		"for (x in 1) { print x; }",
+		"for (a, b in [1]) { print a + b; }",
+		"for (a, a in [[1, 2]]) { print a; }",
+	})
+}
+
+func TestGenerators(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"fun count(n) { let i = 0; while (i < n) { yield i; i = i + 1; } } let s = 0; for (x in count(4)) { s = s + x; } s;", "6"},
+		{"fun g() { for (a in [1, 2]) { for (b in [3, 4]) { yield a * b; } } } let s = []; for (x in g()) { s = [...s, x]; } s;", "[3, 4, 6, 8]"},
+		{"fun g() { yield 1; return; yield 2; } let s = []; for (x in g()) { s = [...s, x]; } s;", "[1]"},
+		{"fun g() { yield 1; } let it = g(); [resume(it), resume(it)];", "[1, nil]"},
+		{"fun g() { let i = 0; while (true) { yield i; i = i + 1; } } let s = 0; for (x in g()) { if (x == 3) { break; } s = s + x; } s;", "3"},
+	})
+	expectErrors(t, []string{
+		"yield 1;",
+		"fun g() { yield 1; return 1 - \"a\"; } for (x in g()) { print x; }",
+	})
+}
+
+func TestAbandonedGeneratorsStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+	_, err := Eval(`
+		fun forever() { let i = 0; while (true) { yield i; i = i + 1; } }
+		fun take() { let g = forever(); return resume(g) + resume(g); }
+		fun outer() { fun gen() { yield 1; yield 2; } let g = gen(); return resume(g); }
+		let i = 0;
+		while (i < 50) { take(); outer(); for (x in forever()) { break; } i = i + 1; }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 50 {
+		runtime.GC()
+		if runtime.NumGoroutine() <= before {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("%d generator goroutines still running", runtime.NumGoroutine()-before)
+}
+
+func TestConcurrency(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"fun sq(x) { return x * x; } let t = spawn sq(4); wait(t);", "16"},
+		{"fun produce(ch) { for (x in [1, 2, 3]) { ch <- x; } close(ch); } let ch = channel(); spawn produce(ch); let s = 0; for (v in ch) { s = s + v; } s;", "6"},
+		{"let ch = channel(1); ch <- 5; <-ch;", "5"},
+		{"let ch = channel(); close(ch); <-ch;", "nil"},
+		{"let a = channel(1); let b = channel(1); b <- 2; let r = 0; select { case let v = <-a: r = v; case let v = <-b: r = v * 10; } r;", "20"},
+		{"let a = channel(); let r = 0; select { case <-a: r = 1; default: r = 2; } r;", "2"},
+		{"let a = channel(1); select { case a <- 7: print 7; } <-a;", "7"},
+	})
+	expectErrors(t, []string{
+		"fun f() { return 1 - \"a\"; } wait(spawn f());",
+		"let ch = channel(); close(ch); ch <- 1;",
+		"let ch = channel(); close(ch); close(ch);",
+		"spawn 1;",
+		"channel(-1);",
+	})
+}
+
+// TypeCheck runs the pipeline up to the type checker and reports whether
+// it found errors
+func TypeCheck(source string) bool {
+	lox := Lox{
+		Interpreter: CreateAndSetupInterpreter(),
+		TypeCheck:   true,
+	}
+	tokens := CreateScanner(source, &lox).scanTokens()
+	statements, _ := CreateParser(tokens, &lox).parse()
+	if lox.HadError {
+		return true
+	}
+	CreateResolver(lox.Interpreter, &lox).resolve(statements)
+	CreateTypeChecker(&lox).check(statements)
+	return lox.HadError
+}
+
+// Output printed by f
+func captureOutput(f func()) string {
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	output, _ := io.ReadAll(r)
+	return string(output)
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"let x: number = 1; x + 1;", "2"},
+		{"fun f(a: string, b: number? = nil): bool { return a == \"x\"; } f(\"x\");", "true"},
+	})
+
+	valid := []string{
+		"let x: number = 1; x = 2.5;",
+		"let x: int? = nil;",
+		"fun f(a: string): string { return a + 1; } f(\"a\");",
+		"fun f(a) { return a - 1; } let s: string = \"a\"; f(s);",
+		"let d = \"dynamic\"; d - 1;",
+		"fun f(a: number, b: number = 2): number { return a * b; } f(b: 3, a: 1);",
+	}
+	for i, source := range valid {
+		if TypeCheck(source) {
+			t.Errorf("Wrong on valid %d : unexpected type error\n", i)
+		}
+	}
+
+	invalid := []string{
+		"\"a\" - 1;",
+		"let x: number = \"a\";",
+		"let x: number = 1; x = true;",
+		"let x: int = nil;",
+		"let x: strin = \"a\";",
+		"fun f(a: string) { return a; } f(1);",
+		"fun f(a: string, b: int = 1) { return a; } f(\"a\", b: \"b\");",
+		"fun f(): bool { return 1; }",
+		"let s: string = \"a\"; s();",
+		"let b = 1 + true;",
+		"let x: int = 9223372036854775807 + 1;",
+		"let y: number = 1.5 + 1d;",
+		"fun f(d: decimal) { return d * 0.5; }",
+	}
+	for i, source := range invalid {
+		if !TypeCheck(source) {
+			t.Errorf("Wrong on invalid %d : expected a type error\n", i)
+		}
+	}
+
+	output := captureOutput(func() {
+		TypeCheck("fun g(n: number): string { return \"a\"; } fun f(s: string) { return s; } f(g(\"x\"));")
+	})
+	if n := strings.Count(output, "Compile Error"); n != 1 {
+		t.Errorf("Expected the error in the argument once, got %d", n)
+	}
+
+	output = captureOutput(func() { TypeCheck("fun f(...a: int) { return a; }") })
+	if !strings.Contains(output, "Rest parameter can't be annotated") {
+		t.Errorf("Expected the annotation on the rest parameter to be rejected, got %s", output)
+	}
+}
+
+// Reports whether the inference pass warns about the source
+func InferenceWarns(source string) bool {
+	lox := Lox{Interpreter: CreateAndSetupInterpreter()}
+	tokens := CreateScanner(source, &lox).scanTokens()
+	statements, _ := CreateParser(tokens, &lox).parse()
+	CreateResolver(lox.Interpreter, &lox).resolve(statements)
+	CreateTypeInference(&lox).check(statements)
+	return lox.HadWarning
+}
+
+func TestTypeInference(t *testing.T) {
+	warned := []string{
+		"let s = \"a\"; s - 1;",
+		"let f = 1; f();",
+		"let x = 1; x = \"a\"; -x;",
+		"let b = true; let c = b + 1;",
+		"let n = 1; n[0];",
+		"let x = \"a\"; while (false) { x = \"b\"; } x * 2;",
+		"fun f() { let s = \"a\"; return s / 2; }",
+		"print 1.5 + 1d;",
+		"let f = 1.5; let d = 1d; d - f;",
+	}
+	for _, source := range warned {
+		if !InferenceWarns(source) {
+			t.Errorf("Expected a warning for %s", source)
+		}
+	}
+
+	silent := []string{
+		"let x = 1; x - 1;",
+		"let x = nil; if (true) { x = 1; } x - 1;",
+		"let x = \"a\"; if (true) { x = 1; } else { x = 2; } x - 1;",
+		"let x = \"a\"; fun g() { x = 1; } g(); x - 1;",
+		"let x = \"a\"; let i = 0; while (i < 2) { if (i == 1) { x - 1; } x = 1; i = i + 1; }",
+		"let x = 1; fun g() { return x - 1; } x = \"a\";",
+		"let s: number = \"a\";",
+		"fun f(a) { return a - 1; } f(\"a\");",
+		"let x = true ? 1 : 2; x - 1;",
+	}
+	for _, source := range silent {
+		if InferenceWarns(source) {
+			t.Errorf("Unexpected warning for %s", source)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	enums := "enum Color { Red, Green, Blue } enum Option { Some(value), None } "
+	expectValues(t, [][2]string{
+		{enums + "Color.Red;", "Color.Red"},
+		{enums + "Color.Red == Color.Red;", "true"},
+		{enums + "Color.Red == Color.Blue;", "false"},
+		{enums + "Option.Some([1]) == Option.Some([1]);", "true"},
+		{enums + "Option.Some(2).value;", "2"},
+		{enums + "let m = {Option.Some(1): 3}; m[Option.Some(1.0)];", "3"},
+		{enums + "let m = {Option.Some(1): 3, Option.None: 4}; m[Option.None];", "4"},
+		{enums + "Option.Some(2) in {Option.Some(1): 3};", "false"},
+		{enums + "let m = {(Color.Red, Option.Some(1)): 3}; m[(Color.Red, Option.Some(1))];", "3"},
+		{enums + "let names = []; for (c in Color) { names = [...names, c]; } names;", "[Color.Red, Color.Green, Color.Blue]"},
+		{enums + "match (Option.Some(2)) { Option.Some(x) => x + 1, Option.None => 0 };", "3"},
+		{enums + "match (Color.Green) { Color.Red | Color.Blue => 1, Color.Green => 2 };", "2"},
+		{"fun f() { enum Dir { Up, Down } return match (Dir.Down) { Dir.Up => 1, Dir.Down => 2 }; } f();", "2"},
+	})
+	expectErrors(t, []string{
+		enums + "Color.Purple;",
+		enums + "Option.Some();",
+		enums + "Color = 1;",
+		enums + "match (Option.None) { Option.Some(x, y) => 1, _ => 2 };",
+		"enum Empty {}",
+		"enum Twice { A, A }",
+	})
+
+	warned := []string{
+		enums + "match (Color.Red) { Color.Red => 1, Color.Green => 2 };",
+		enums + "match (Option.None) { Option.Some(1) => 1, Option.None => 2 };",
+		enums + "switch (Color.Red) { case Color.Red, Color.Green: print 1; }",
+		enums + "Color.Purple;",
+	}
+	for _, source := range warned {
+		if !InferenceWarns(source) {
+			t.Errorf("Expected a warning for %s", source)
+		}
+	}
+	exhaustive := []string{
+		enums + "match (Color.Red) { Color.Red | Color.Green => 1, Color.Blue => 2 };",
+		enums + "match (Option.None) { Option.Some(_) => 1, Option.None => 2 };",
+		enums + "match (Color.Red) { Color.Red => 1, _ => 2 };",
+		enums + "switch (Color.Red) { case Color.Red: print 1; default: print 2; }",
+	}
+	for _, source := range exhaustive {
+		if InferenceWarns(source) {
+			t.Errorf("Unexpected warning for %s", source)
+		}
+	}
+}
+
+func TestTuples(t *testing.T) {
+	divmod := "fun divmod(a, b) { return a / b, a - a / b * b; } "
+	expectValues(t, [][2]string{
+		{divmod + "divmod(7, 2);", "(3, 1)"},
+		{divmod + "let q, r = divmod(7, 2); q * 10 + r;", "31"},
+		{divmod + "const q, _ = divmod(9, 2); q;", "4"},
+		{"let a = 1; let b = 2; a, b = b, a; [a, b];", "[2, 1]"},
+		{"let t = (1, \"a\"); t[1];", "a"},
+		{"(1,);", "(1,)"},
+		{"();", "()"},
+		{"(1 + 2);", "3"},
+		{"(1, [2]) == (1, [2]);", "true"},
+		{"(1, 2) == [1, 2];", "false"},
+		{"len((1, 2, 3));", "3"},
+		{"let m = {(1, 2): \"pair\"}; m[1.0, 2];", "pair"},
+		{"match ((1, 2)) { (x, 2) => x, _ => 0 };", "1"},
+		{divmod + "fun add(a, b) { return a + b; } add(...divmod(9, 4));", "3"},
+	})
+	expectErrors(t, []string{
+		"let a, b = (1, 2, 3);",
+		"let a, b = [1, 2];",
+		"(1, 2)[2];",
+		"let m = {([1], 2): 1};",
+	})
+}
+
+func TestLoopElseAndDoWhile(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"let n = 0; do { n = n + 1; } while (false); n;", "1"},
+		{"let n = 0; do { n = n + 1; } while (n < 5); n;", "5"},
+		{"let n = 0; do { n = n + 1; if (n == 2) { break; } } while (true); n;", "2"},
+		{"let found = nil; for (x in [1, 2, 3]) { if (x > 3) { found = x; break; } } else { found = \"none\"; } found;", "none"},
+		{"let found = nil; for (x in [1, 4, 5]) { if (x > 3) { found = x; break; } } else { found = \"none\"; } found;", "4"},
+		{"let r = 0; let i = 0; while (i < 3) { i = i + 1; } else { r = i; } r;", "3"},
+		{"let r = 0; for (let i = 0; i < 3; i = i + 1) { if (i == 1) { break; } } else { r = 1; } r;", "0"},
+		{"let r = 0; for (x in []) { } else { r = 1; } r;", "1"},
+		{"let r = 0; for (a in [1, 2]) { for (b in [1]) { } else { break; } r = r + 1; } r;", "0"},
+		// The else of an if isn't taken by the loop it runs
+		{"let r = 0; if (false) while (false) {} else { r = 1; } r;", "1"},
+		{"let r = 0; if (true) for (x in []) {} else { r = 1; } r;", "0"},
+		{"let r = 0; if (true) { for (x in []) {} else { r = 1; } } r;", "1"},
+		{"let r = 0; if (false) if (true) while (false) {} else { r = 1; } r;", "0"},
+	})
+	expectErrors(t, []string{
+		"do { } while false;",
+		"do { } while (true)",
+		"do { }",
+	})
+}
+
+func TestRanges(t *testing.T) {
+	expectValues(t, [][2]string{
+		{"0..10;", "0..10"},
+		{"0..=10 step 5;", "0..=10 step 5"},
+		{"let s = []; for (i in 0..3) { s = [...s, i]; } s;", "[0, 1, 2]"},
+		{"let s = []; for (i in 10..0 step -4) { s = [...s, i]; } s;", "[10, 6, 2]"},
+		{"let s = []; for (i in 3..0) { s = [...s, i]; } s;", "[]"},
+		{"len(0..10 step 3);", "4"},
+		{"len(0..=0);", "1"},
+		{"4 in 0..10 step 2;", "true"},
+		{"5 in 0..10 step 2;", "false"},
+		{"10 in 0..10;", "false"},
+		{"10 in 0..=10;", "true"},
+		{"2.0 in 0..3;", "true"},
+		{"let n = 2; 0..n * 2;", "0..4"},
+		{"[1, 2, 3, 4][1..3];", "[2, 3]"},
+		{"\"héllo\"[1..=3];", "éll"},
+		{"(1, 2, 3)[0..3 step 2];", "(1, 3)"},
+		{"2 in [1, 2];", "true"},
+		{"\"ell\" in \"hello\";", "true"},
+		{"\"b\" in {a: 1};", "false"},
+		{"(0..3) == (0..3);", "true"},
+		{"(0..3) == (0..=2);", "false"},
+		{"len(0..9223372036854775807);", "9223372036854775807"},
+		{"len(0..=9223372036854775807);", "9223372036854775808"},
+		{"len(-9223372036854775807..9223372036854775807);", "18446744073709551614"},
+		{"let m = -9223372036854775807 - 1; len(m..=9223372036854775807);", "18446744073709551616"},
+		{"[...0..3, 3];", "[0, 1, 2, 3]"},
+		{"fun f(a, b) { return a + b; } f(...1..=2);", "3"},
+		{"[...(3..0)];", "[]"},
+		{"5 in 0..=9223372036854775807;", "true"},
+		{"9223372036854775807 in 0..=9223372036854775807;", "true"},
+		{"let s = []; for (i in 9223372036854775806..=9223372036854775807) { s = [...s, i]; } s;", "[9223372036854775806, 9223372036854775807]"},
+		{"let m = -9223372036854775807 - 1; let s = []; for (i in 9223372036854775807..m step m) { s = [...s, i]; } s;", "[9223372036854775807, -1]"},
+		{"let m = -9223372036854775807 - 1; len(0..=m step m);", "2"},
+		{"let m = -9223372036854775807 - 1; m in 0..=m step m;", "true"},
+	})
+	expectErrors(t, []string{
+		"let m = -9223372036854775807 - 1; [...m..=9223372036854775807];",
+		"0..1.5;",
+		"0..3 step 0;",
+		"[1, 2][0..3];",
+		"1 in 3;",
+		"1 in \"1\";",
+		"0..1..2;",
+	})
+}